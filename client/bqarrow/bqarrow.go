@@ -0,0 +1,417 @@
+// Package bqarrow converts query results to Apache Arrow record batches and writes them to Parquet files, mapping
+// BigQuery types to Arrow logical types: TIMESTAMP to microsecond UTC timestamps, DATETIME to microsecond timestamps
+// without time zone, DATE to date32, TIME to microsecond time64, NUMERIC to decimal128(38, 9), BIGNUMERIC to
+// decimal256(76, 38), RECORD to structs and REPEATED fields to lists. GEOGRAPHY, JSON and other types are strings
+//
+// An example use is:
+//
+//	it := bqClient.Rows(ctx, 10000, dataset, project, query)
+//	defer it.Close()
+//	err := bqarrow.WriteParquet(file, it, bqarrow.Options{})
+package bqarrow
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/decimal256"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/dailyburn/bigquery/client"
+	"google.golang.org/api/iterator"
+)
+
+// The precision and scale of the NUMERIC and BIGNUMERIC types
+const (
+	numericPrecision    = 38
+	numericScale        = 9
+	bigNumericPrecision = 76
+	bigNumericScale     = 38
+)
+
+// Options configures NewRecordReader and WriteParquet
+type Options struct {
+	Allocator memory.Allocator // memory.DefaultAllocator by default
+
+	Compression  string // Parquet only: snappy (default), zstd, gzip, brotli, lz4 or none
+	RowGroupSize int64  // Parquet only, the maximum number of rows of a row group, 1M by default
+}
+
+func (o Options) allocator() memory.Allocator {
+	if o.Allocator == nil {
+		return memory.DefaultAllocator
+	}
+	return o.Allocator
+}
+
+// Schema returns the Arrow schema of query results with the given BigQuery schema. REQUIRED fields are not nullable,
+// nor are REPEATED fields as BigQuery returns empty arrays instead of NULL
+func Schema(s client.Schema) (*arrow.Schema, error) {
+	fields, err := arrowFields(s)
+	if err != nil {
+		return nil, err
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+func arrowFields(s client.Schema) ([]arrow.Field, error) {
+	fields := make([]arrow.Field, len(s))
+	for i, f := range s {
+		t, err := arrowType(f)
+		if err != nil {
+			return nil, err
+		}
+
+		mode := strings.ToUpper(f.Mode)
+		if mode == client.ModeRepeated {
+			t = arrow.ListOfNonNullable(t)
+		}
+		fields[i] = arrow.Field{Name: f.Name, Type: t, Nullable: mode != client.ModeRequired && mode != client.ModeRepeated}
+	}
+	return fields, nil
+}
+
+// arrowType returns the type of the values of the field, ignoring its mode
+func arrowType(f *client.Field) (arrow.DataType, error) {
+	switch strings.ToUpper(f.Type) {
+	case "STRING", "GEOGRAPHY", "JSON", "INTERVAL":
+		return arrow.BinaryTypes.String, nil
+	case "BYTES":
+		return arrow.BinaryTypes.Binary, nil
+	case "INTEGER", "INT64":
+		return arrow.PrimitiveTypes.Int64, nil
+	case "FLOAT", "FLOAT64":
+		return arrow.PrimitiveTypes.Float64, nil
+	case "BOOLEAN", "BOOL":
+		return arrow.FixedWidthTypes.Boolean, nil
+	case "NUMERIC", "DECIMAL":
+		return &arrow.Decimal128Type{Precision: numericPrecision, Scale: numericScale}, nil
+	case "BIGNUMERIC", "BIGDECIMAL":
+		return &arrow.Decimal256Type{Precision: bigNumericPrecision, Scale: bigNumericScale}, nil
+	case "TIMESTAMP":
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}, nil
+	case "DATETIME":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}, nil
+	case "DATE":
+		return arrow.FixedWidthTypes.Date32, nil
+	case "TIME":
+		return arrow.FixedWidthTypes.Time64us, nil
+	case "RECORD", "STRUCT":
+		if len(f.Fields) == 0 {
+			return nil, fmt.Errorf("RECORD field %s has no fields", f.Name)
+		}
+		fields, err := arrowFields(f.Fields)
+		if err != nil {
+			return nil, err
+		}
+		return arrow.StructOf(fields...), nil
+	default:
+		return arrow.BinaryTypes.String, nil
+	}
+}
+
+// RecordReader is an array.RecordReader returning a record batch per page of query results
+type RecordReader struct {
+	refs   int64
+	it     *client.RowIterator
+	fields client.Schema
+	schema *arrow.Schema
+	mem    memory.Allocator
+
+	builder *array.RecordBuilder
+	first   [][]interface{} // the first page, read to learn the schema
+	rec     arrow.RecordBatch
+	err     error
+}
+
+var _ array.RecordReader = (*RecordReader)(nil)
+
+// NewRecordReader reads the first page of it to learn the schema of the results and returns a reader over its pages.
+// The reader does not close it, release the reader when done
+func NewRecordReader(it *client.RowIterator, opts Options) (*RecordReader, error) {
+	first, err := it.NextPage()
+	if err != nil && err != iterator.Done {
+		return nil, err
+	}
+
+	fields := it.Schema()
+	if fields == nil {
+		return nil, fmt.Errorf("the query results carry no schema")
+	}
+	schema, err := Schema(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecordReader{
+		refs:    1,
+		it:      it,
+		fields:  fields,
+		schema:  schema,
+		mem:     opts.allocator(),
+		builder: array.NewRecordBuilder(opts.allocator(), schema),
+		first:   first,
+	}, nil
+}
+
+// Retain increases the reference count of the reader
+func (r *RecordReader) Retain() {
+	atomic.AddInt64(&r.refs, 1)
+}
+
+// Release decreases the reference count of the reader, releasing its memory when it reaches zero
+func (r *RecordReader) Release() {
+	if atomic.AddInt64(&r.refs, -1) == 0 {
+		if r.rec != nil {
+			r.rec.Release()
+			r.rec = nil
+		}
+		r.builder.Release()
+	}
+}
+
+// Schema returns the Arrow schema of the records
+func (r *RecordReader) Schema() *arrow.Schema {
+	return r.schema
+}
+
+// Next reads the next page of results as a record batch, it returns false once every page has been read or on error,
+// see Err
+func (r *RecordReader) Next() bool {
+	if r.rec != nil {
+		r.rec.Release()
+		r.rec = nil
+	}
+	if r.err != nil {
+		return false
+	}
+
+	rows := r.first
+	r.first = nil
+	if rows == nil {
+		var err error
+		if rows, err = r.it.NextPage(); err != nil {
+			if err != iterator.Done {
+				r.err = err
+			}
+			return false
+		}
+	}
+
+	for _, row := range rows {
+		for i, f := range r.fields {
+			var v interface{}
+			if i < len(row) {
+				v = row[i]
+			}
+			if err := appendValue(r.builder.Field(i), f, v); err != nil {
+				// the columns of the builder now have uneven lengths, down to the nested fields, replace it
+				r.err = err
+				r.builder.Release()
+				r.builder = array.NewRecordBuilder(r.mem, r.schema)
+				return false
+			}
+		}
+	}
+
+	r.rec = r.builder.NewRecordBatch()
+	return true
+}
+
+// RecordBatch returns the current record batch, it is valid until the next call to Next
+func (r *RecordReader) RecordBatch() arrow.RecordBatch {
+	return r.rec
+}
+
+// Record returns the current record batch
+//
+// Deprecated: Use RecordBatch instead
+func (r *RecordReader) Record() arrow.RecordBatch {
+	return r.rec
+}
+
+// Err returns the error that stopped Next, if any
+func (r *RecordReader) Err() error {
+	return r.err
+}
+
+// appendValue appends the value of the field, as decoded by the client, to b
+func appendValue(b array.Builder, f *client.Field, v interface{}) error {
+	if v == nil {
+		b.AppendNull()
+		return nil
+	}
+
+	if strings.ToUpper(f.Mode) == client.ModeRepeated {
+		values, ok := listValues(v)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", f.Name, v)
+		}
+
+		lb := b.(*array.ListBuilder)
+		lb.Append(true)
+		elem := *f
+		elem.Mode = client.ModeRequired
+		record := isRecord(f)
+		for _, ev := range values {
+			// the client leaves the cells of repeated scalar fields wrapped in {"v": value}
+			if m, ok := ev.(map[string]interface{}); ok && !record {
+				ev = m["v"]
+			}
+			if err := appendValue(lb.ValueBuilder(), &elem, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if isRecord(f) {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a record, got %T", f.Name, v)
+		}
+		sb := b.(*array.StructBuilder)
+		sb.Append(true)
+		for i, nf := range f.Fields {
+			if err := appendValue(sb.FieldBuilder(i), nf, m[nf.Name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s := text(v)
+	var err error
+	switch b := b.(type) {
+	case *array.StringBuilder:
+		b.Append(s)
+	case *array.BinaryBuilder:
+		var data []byte
+		if data, err = base64.StdEncoding.DecodeString(s); err == nil {
+			b.Append(data)
+		}
+	case *array.Int64Builder:
+		var n int64
+		if n, err = strconv.ParseInt(s, 10, 64); err == nil {
+			b.Append(n)
+		}
+	case *array.Float64Builder:
+		var n float64
+		if n, err = strconv.ParseFloat(s, 64); err == nil {
+			b.Append(n)
+		}
+	case *array.BooleanBuilder:
+		var t bool
+		if t, err = strconv.ParseBool(s); err == nil {
+			b.Append(t)
+		}
+	case *array.Decimal128Builder:
+		var n decimal128.Num
+		if n, err = decimal128.FromString(s, numericPrecision, numericScale); err == nil {
+			b.Append(n)
+		}
+	case *array.Decimal256Builder:
+		var n decimal256.Num
+		if n, err = decimal256.FromString(s, bigNumericPrecision, bigNumericScale); err == nil {
+			b.Append(n)
+		}
+	case *array.TimestampBuilder:
+		var ts int64
+		if strings.ToUpper(f.Type) == "TIMESTAMP" {
+			ts, err = timestampMicros(s)
+		} else {
+			ts, err = datetimeMicros(s)
+		}
+		if err == nil {
+			b.Append(arrow.Timestamp(ts))
+		}
+	case *array.Date32Builder:
+		var d time.Time
+		if d, err = time.Parse("2006-01-02", s); err == nil {
+			b.Append(arrow.Date32FromTime(d))
+		}
+	case *array.Time64Builder:
+		var t arrow.Time64
+		if t, err = arrow.Time64FromString(s, arrow.Microsecond); err == nil {
+			b.Append(t)
+		}
+	default:
+		err = fmt.Errorf("unsupported builder %T", b)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid %s value %q: %v", f.Name, f.Type, s, err)
+	}
+	return nil
+}
+
+func isRecord(f *client.Field) bool {
+	t := strings.ToUpper(f.Type)
+	return t == "RECORD" || t == "STRUCT"
+}
+
+// listValues returns the elements of a REPEATED value, repeated RECORD values are decoded as slices of maps
+func listValues(v interface{}) ([]interface{}, bool) {
+	switch vs := v.(type) {
+	case []interface{}:
+		return vs, true
+	case []map[string]interface{}:
+		values := make([]interface{}, len(vs))
+		for i, m := range vs {
+			values[i] = m
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
+
+// text returns the string form of a value, the API returns every scalar as a string
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// timestampMicros parses a TIMESTAMP returned by the API, the number of seconds since the epoch in floating point
+// notation, e.g. 1.405099396123456E9, without losing the microseconds to float64 rounding. RFC 3339 timestamps are
+// accepted too
+func timestampMicros(s string) (int64, error) {
+	if r, ok := new(big.Rat).SetString(s); ok {
+		r.Mul(r, big.NewRat(int64(time.Second/time.Microsecond), 1))
+		micros := new(big.Int).Quo(r.Num(), r.Denom())
+		if !micros.IsInt64() {
+			return 0, fmt.Errorf("out of range")
+		}
+		return micros.Int64(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMicro(), nil
+}
+
+// datetimeMicros parses a DATETIME, a civil date and time, to microseconds since the epoch of the wall clock
+func datetimeMicros(s string) (int64, error) {
+	t, err := time.Parse("2006-01-02T15:04:05.999999999", strings.Replace(s, " ", "T", 1))
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMicro(), nil
+}
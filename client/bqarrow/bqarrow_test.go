@@ -0,0 +1,107 @@
+package bqarrow_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/bqarrow"
+)
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		field    client.Field
+		want     arrow.DataType
+		nullable bool
+	}{
+		{client.Field{Type: "STRING"}, arrow.BinaryTypes.String, true},
+		{client.Field{Type: "JSON"}, arrow.BinaryTypes.String, true},
+		{client.Field{Type: "BYTES"}, arrow.BinaryTypes.Binary, true},
+		{client.Field{Type: "INTEGER", Mode: client.ModeRequired}, arrow.PrimitiveTypes.Int64, false},
+		{client.Field{Type: "INT64"}, arrow.PrimitiveTypes.Int64, true},
+		{client.Field{Type: "FLOAT"}, arrow.PrimitiveTypes.Float64, true},
+		{client.Field{Type: "BOOL"}, arrow.FixedWidthTypes.Boolean, true},
+		{client.Field{Type: "NUMERIC"}, &arrow.Decimal128Type{Precision: 38, Scale: 9}, true},
+		{client.Field{Type: "BIGNUMERIC"}, &arrow.Decimal256Type{Precision: 76, Scale: 38}, true},
+		{client.Field{Type: "TIMESTAMP"}, &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}, true},
+		{client.Field{Type: "DATETIME"}, &arrow.TimestampType{Unit: arrow.Microsecond}, true},
+		{client.Field{Type: "DATE"}, arrow.FixedWidthTypes.Date32, true},
+		{client.Field{Type: "TIME"}, arrow.FixedWidthTypes.Time64us, true},
+		{client.Field{Type: "STRING", Mode: client.ModeRepeated}, arrow.ListOfNonNullable(arrow.BinaryTypes.String), false},
+		{
+			client.Field{Type: "RECORD", Fields: client.Schema{{Name: "id", Type: "INTEGER", Mode: client.ModeRequired}}},
+			arrow.StructOf(arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int64}),
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field.Type+" "+tt.field.Mode, func(t *testing.T) {
+			f := tt.field
+			f.Name = "f"
+			schema, err := bqarrow.Schema(client.Schema{&f})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := schema.Field(0)
+			if !arrow.TypeEqual(got.Type, tt.want) {
+				t.Errorf("type = %s, want %s", got.Type, tt.want)
+			}
+			if got.Nullable != tt.nullable {
+				t.Errorf("nullable = %v, want %v", got.Nullable, tt.nullable)
+			}
+		})
+	}
+
+	if _, err := bqarrow.Schema(client.Schema{{Name: "r", Type: "RECORD"}}); err == nil {
+		t.Error("expected an error for a RECORD without fields")
+	}
+}
+
+func TestRecordReaderInvalidValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema client.Schema
+		bad    interface{}
+	}{
+		{"scalar", client.Schema{{Name: "a", Type: "STRING"}, {Name: "b", Type: "TIMESTAMP"}}, "not a timestamp"},
+		{
+			"nested",
+			client.Schema{{Name: "a", Type: "STRING"}, {Name: "b", Type: "RECORD", Fields: client.Schema{
+				{Name: "x", Type: "STRING"},
+				{Name: "y", Type: "INTEGER"},
+			}}},
+			map[string]interface{}{"x": "x", "y": "not an integer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+			defer mem.AssertSize(t, 0)
+
+			dataChan := make(chan client.Data, 1)
+			dataChan <- client.Data{Headers: []string{"a", "b"}, Rows: [][]interface{}{{"ok", nil}, {"bad", tt.bad}}, Schema: tt.schema}
+			close(dataChan)
+
+			rr, err := bqarrow.NewRecordReader(client.NewRowIterator(dataChan), bqarrow.Options{Allocator: mem})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rr.Release()
+
+			if rr.Next() {
+				t.Fatal("Next succeeded with an invalid value")
+			}
+			if err = rr.Err(); err == nil || !strings.Contains(err.Error(), "not a") {
+				t.Errorf("err = %v, want the invalid value", err)
+			}
+			if rr.Next() {
+				t.Error("Next succeeded after an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package bqarrow
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/dailyburn/bigquery/client"
+)
+
+const defaultRowGroupSize = 1024 * 1024
+
+// WriteParquet writes the rows of it to w as a Parquet file, converting and writing them page by page as they are
+// read. The Arrow schema is stored in the file metadata so that Arrow readers restore the exact types. w is not closed
+func WriteParquet(w io.Writer, it *client.RowIterator, opts Options) error {
+	codec, err := compression(opts.Compression)
+	if err != nil {
+		return err
+	}
+	rowGroupSize := opts.RowGroupSize
+	if rowGroupSize <= 0 {
+		rowGroupSize = defaultRowGroupSize
+	}
+
+	rr, err := NewRecordReader(it, opts)
+	if err != nil {
+		return err
+	}
+	defer rr.Release()
+
+	props := parquet.NewWriterProperties(
+		parquet.WithAllocator(opts.allocator()),
+		parquet.WithCompression(codec),
+		parquet.WithMaxRowGroupLength(rowGroupSize),
+	)
+	arrowProps := pqarrow.NewArrowWriterProperties(pqarrow.WithAllocator(opts.allocator()), pqarrow.WithStoreSchema())
+
+	// hide any Close method of w, the file writer closes writers implementing io.Closer
+	fw, err := pqarrow.NewFileWriter(rr.Schema(), struct{ io.Writer }{w}, props, arrowProps)
+	if err != nil {
+		return err
+	}
+
+	for rr.Next() {
+		if err = fw.WriteBuffered(rr.RecordBatch()); err != nil {
+			fw.Close()
+			return err
+		}
+	}
+	if err = rr.Err(); err != nil {
+		fw.Close()
+		return err
+	}
+
+	return fw.Close()
+}
+
+func compression(name string) (compress.Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "snappy":
+		return compress.Codecs.Snappy, nil
+	case "zstd":
+		return compress.Codecs.Zstd, nil
+	case "gzip":
+		return compress.Codecs.Gzip, nil
+	case "brotli":
+		return compress.Codecs.Brotli, nil
+	case "lz4":
+		return compress.Codecs.Lz4Raw, nil
+	case "none":
+		return compress.Codecs.Uncompressed, nil
+	default:
+		return compress.Codecs.Uncompressed, fmt.Errorf("unknown Parquet compression %q", name)
+	}
+}
@@ -1,16 +1,20 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"io/ioutil"
+	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/bigquery/v2"
-	"github.com/pkg/errors"
 )
 
 const authURL = "https://accounts.google.com/o/oauth2/auth"
@@ -19,31 +23,46 @@ const tokenURL = "https://accounts.google.com/o/oauth2/token"
 const defaultPageSize = 5000
 const defaultRequestTimeout = 60000
 const maxRequestRetry = 5
+const defaultTempTablePrefix = "bq_tmp"
+const defaultTempTableExpiration = time.Hour
 
 // Client a big query client instance
 type Client struct {
-	pemPath           string
-	token             *oauth2.Token
-	service           *bigquery.Service
-	allowLargeResults bool
-	tempTableName     string
-	flattenResults    bool
-	PrintDebug        bool
-	RequestTimeout    int64 // how long (in milliseconds) to try to create requests for large data (not a query timeout); defaults to 60000
+	pemPath             string
+	token               *oauth2.Token
+	service             *bigquery.Service
+	allowLargeResults   bool
+	tempTableName       string
+	flattenResults      bool
+	scratchDataset      string
+	tempTableExpiration time.Duration
+	maxJobWait          time.Duration
+	queryOptions        QueryOptions
+	logger              Logger
+	tracerProvider      trace.TracerProvider
+	metricsRecorder     MetricsRecorder
+	interceptors        []Interceptor
+	httpClient          *http.Client
+	PrintDebug          bool  // logs debug output to stdout when no logger is configured, see WithLogger
+	RequestTimeout      int64 // how long (in milliseconds) to try to create requests for large data (not a query timeout); defaults to 60000
 }
 
-// Data is a containing type used for Async data response handling including Headers, Rows and an Error that will be populated in the event of an Error querying
+// Data is a containing type used for Async data response handling including Headers, Rows and an Error that will be populated in the event of an Error querying.
+// Once every row has been sent a last payload carrying the Headers and the Stats of the query job is sent before the channel is closed
 type Data struct {
 	Headers []string
 	Rows    [][]interface{}
+	Schema  Schema // the schema of the results, RECORD columns list their nested fields
+	Stats   *QueryStats
 	Err     error
 }
 
 // New instantiates a new client with the given params and return a reference to it
 func New(pemPath string, options ...func(*Client) error) *Client {
 	c := Client{
-		pemPath:        pemPath,
-		RequestTimeout: defaultRequestTimeout,
+		pemPath:             pemPath,
+		RequestTimeout:      defaultRequestTimeout,
+		tempTableExpiration: defaultTempTableExpiration,
 	}
 
 	c.PrintDebug = false
@@ -59,18 +78,59 @@ func New(pemPath string, options ...func(*Client) error) *Client {
 }
 
 // AllowLargeResults is a configuration function that can be used to enable the AllowLargeResults setting
-// of a bigquery request, as well as a temp table name to use to build the result data
+// of a bigquery request, as well as a temp table name prefix to use to build the result data. Every query writes its
+// results to its own uniquely named temp table which is dropped once the results are consumed, see ScratchDataset
 //
 // An example use is:
 //
 // client.New(pemPath, serviceAccountEmailAddress, serviceUserAccountClientID, clientSecret, client.AllowLargeResults(true, "tempTableName"))
-//
 func AllowLargeResults(shouldAllow bool, tempTableName string, flattenResults bool) func(*Client) error {
 	return func(c *Client) error {
 		return c.setAllowLargeResults(shouldAllow, tempTableName, flattenResults)
 	}
 }
 
+// ScratchDataset is a configuration function that sets the dataset the temp tables used by AllowLargeResults are
+// created in, and how long they are kept if they can not be dropped. By default temp tables are created in the dataset
+// of the query and expire after an hour
+func ScratchDataset(datasetID string, expiration time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if expiration <= 0 {
+			return fmt.Errorf("invalid temp table expiration %s", expiration)
+		}
+		c.scratchDataset = datasetID
+		c.tempTableExpiration = expiration
+		return nil
+	}
+}
+
+// MaxJobWait is a configuration function that bounds how long the client waits for a job to complete, while paging
+// over query results or running QueryToTable, Load and Extract, before failing with a *JobWaitError. By default it
+// waits until the context is done
+func MaxJobWait(d time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if d < 0 {
+			return fmt.Errorf("invalid max job wait %s", d)
+		}
+		c.maxJobWait = d
+		return nil
+	}
+}
+
+// WithHTTPClient is a configuration function that sets the HTTP client the API requests are sent with instead of one
+// authorized with the credentials file, which is then not read. The client must authorize the requests itself, e.g.
+// one returned by google.DefaultClient, or not need to as the replaying clienttest.Recorder
+//
+// An example use is:
+//
+// client.New("", client.WithHTTPClient(recorder.Client()))
+func WithHTTPClient(hc *http.Client) func(*Client) error {
+	return func(c *Client) error {
+		c.httpClient = hc
+		return nil
+	}
+}
+
 // setAllowLargeResults - private function to set the AllowLargeResults and tempTableName values
 func (c *Client) setAllowLargeResults(shouldAllow bool, tempTableName string, flattenResults bool) error {
 	c.allowLargeResults = shouldAllow
@@ -80,27 +140,45 @@ func (c *Client) setAllowLargeResults(shouldAllow bool, tempTableName string, fl
 }
 
 // connect - opens a new connection to bigquery, reusing the token if possible or regenerating a new auth token if required
-func (c *Client) connect() (*bigquery.Service, error) {
+func (c *Client) connect(ctx context.Context) (service *bigquery.Service, err error) {
 	if c.token != nil {
 		if !c.token.Valid() && c.service != nil {
 			return c.service, nil
 		}
 	}
 
+	_, span := c.startSpan(ctx, "connect")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	if c.httpClient != nil {
+		service, err = bigquery.New(c.withInterceptors(c.httpClient))
+		if err != nil {
+			return nil, err
+		}
+
+		c.service = service
+		return service, nil
+	}
+
 	// generate auth token and create service object
 	//authScope := bigquery.BigqueryScope
 	pemKeyBytes, err := ioutil.ReadFile(c.pemPath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	t, err := google.JWTConfigFromJSON(
 		pemKeyBytes,
 		"https://www.googleapis.com/auth/bigquery")
+	if err != nil {
+		return nil, err
+	}
 	//t := jwt.NewToken(c.accountEmailAddress, bigquery.BigqueryScope, pemKeyBytes)
-	client := t.Client(oauth2.NoContext)
+	client := c.withInterceptors(t.Client(oauth2.NoContext))
 
-	service, err := bigquery.New(client)
+	service, err = bigquery.New(client)
 	if err != nil {
 		return nil, err
 	}
@@ -111,18 +189,23 @@ func (c *Client) connect() (*bigquery.Service, error) {
 
 // InsertRow inserts a new row into the desired project, dataset and table or returns an error
 func (c *Client) InsertRow(projectID, datasetID, tableID string, rowData map[string]interface{}) error {
-	service, err := c.connect()
+	ctx := context.Background()
+	service, err := c.connect(ctx)
 	if err != nil {
 		return err
 	}
 
 	insertRequest := buildBigQueryInsertRequest([]map[string]interface{}{rowData})
 
-	result, err := service.Tabledata.InsertAll(projectID, datasetID, tableID, insertRequest).Do()
+	ac := c.startCall(ctx, "tabledata.insertAll", "project", projectID, "dataset", datasetID, "table", tableID, "rows", 1)
+	sizeCtx, size := withRequestSize(ac.ctx)
+	result, err := service.Tabledata.InsertAll(projectID, datasetID, tableID, insertRequest).Context(sizeCtx).Do()
+	c.recordInsert(projectID, datasetID, tableID, len(insertRequest.Rows), atomic.LoadInt64(size), result, err)
 	if err != nil {
-		c.printDebug("Error inserting row: ", err)
+		ac.end(err)
 		return err
 	}
+	ac.end(nil, "insert_errors", len(result.InsertErrors))
 
 	if len(result.InsertErrors) > 0 {
 		return errors.New("Error inserting row: %s")
@@ -132,17 +215,26 @@ func (c *Client) InsertRow(projectID, datasetID, tableID string, rowData map[str
 }
 
 func (c *Client) InsertRows(projectID, datasetID, tableID string, rows []map[string]interface{}) error {
-	service, err := c.connect()
+	return c.InsertRowsContext(context.Background(), projectID, datasetID, tableID, rows)
+}
+
+// InsertRowsContext is InsertRows running within ctx
+func (c *Client) InsertRowsContext(ctx context.Context, projectID, datasetID, tableID string, rows []map[string]interface{}) error {
+	service, err := c.connect(ctx)
 	if err != nil {
 		return err
 	}
 
 	insertRequest := buildBigQueryInsertRequest(rows)
-	result, err := service.Tabledata.InsertAll(projectID, datasetID, tableID, insertRequest).Do()
+	ac := c.startCall(ctx, "tabledata.insertAll", "project", projectID, "dataset", datasetID, "table", tableID, "rows", len(rows))
+	sizeCtx, size := withRequestSize(ac.ctx)
+	result, err := service.Tabledata.InsertAll(projectID, datasetID, tableID, insertRequest).Context(sizeCtx).Do()
+	c.recordInsert(projectID, datasetID, tableID, len(insertRequest.Rows), atomic.LoadInt64(size), result, err)
 	if err != nil {
-		c.printDebug("Error inserting rows: ", err)
+		ac.end(err)
 		return err
 	}
+	ac.end(nil, "insert_errors", len(result.InsertErrors))
 
 	if len(result.InsertErrors) > 0 {
 		return errors.Errorf("Error inserting rows, first row: %+v", *result.InsertErrors[0].Errors[0])
@@ -169,90 +261,131 @@ func rowToBigQueryJSON(row map[string]interface{}) map[string]bigquery.JsonValue
 }
 
 // AsyncQuery loads the data by paging through the query results and sends back payloads over the dataChan - dataChan sends a payload containing Data objects made up of the headers, rows and an error attribute
-func (c *Client) AsyncQuery(pageSize int, dataset, project, queryStr string, dataChan chan Data) {
-	c.pagedQuery(pageSize, dataset, project, queryStr, dataChan)
+// dataChan is closed once the query is over and its resources are released, after an error too
+func (c *Client) AsyncQuery(pageSize int, dataset, project, queryStr string, dataChan chan Data, opts ...QueryOptions) {
+	c.AsyncQueryContext(context.Background(), pageSize, dataset, project, queryStr, dataChan, opts...)
+}
+
+// AsyncQueryContext is AsyncQuery running within ctx, the spans of the query are children of any span in ctx
+func (c *Client) AsyncQueryContext(ctx context.Context, pageSize int, dataset, project, queryStr string, dataChan chan Data, opts ...QueryOptions) {
+	c.pagedQuery(ctx, pageSize, dataset, project, queryStr, dataChan, c.mergeQueryOptions(opts))
 }
 
 // Query loads the data for the query paging if necessary and return the data rows, headers and error
-func (c *Client) Query(dataset, project, queryStr string) ([][]interface{}, []string, error) {
-	return c.pagedQuery(defaultPageSize, dataset, project, queryStr, nil)
+func (c *Client) Query(dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, error) {
+	return c.QueryContext(context.Background(), dataset, project, queryStr, opts...)
+}
+
+// QueryContext is Query running within ctx, the spans of the query are children of any span in ctx
+func (c *Client) QueryContext(ctx context.Context, dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, error) {
+	rows, headers, _, err := c.pagedQuery(ctx, defaultPageSize, dataset, project, queryStr, nil, c.mergeQueryOptions(opts))
+	return rows, headers, err
 }
 
 // stdPagedQuery executes a query using default job parameters and paging over the results, returning them over the data chan provided
-func (c *Client) stdPagedQuery(service *bigquery.Service, pageSize int, dataset, project, queryStr string, dataChan chan Data) ([][]interface{}, []string, error) {
-	c.printDebug("std paged query")
-	datasetRef := &bigquery.DatasetReference{
-		DatasetId: dataset,
-		ProjectId: project,
+func (c *Client) stdPagedQuery(ctx context.Context, service *bigquery.Service, pageSize int, dataset, project, queryStr string, dataChan chan Data, opts QueryOptions) ([][]interface{}, []string, *QueryStats, error) {
+	c.log().Debug("std paged query", "project", project, "dataset", dataset)
+	if opts.needsJob() {
+		// jobs.query does not support batch priority, job timeouts or caller provided job IDs
+		job, err := newQueryJob(queryStr, project, dataset, nil, QueryJobConfig{QueryOptions: opts})
+		if err != nil {
+			if dataChan != nil {
+				dataChan <- Data{Err: err}
+			}
+			return nil, nil, nil, err
+		}
+		return c.jobPagedQuery(ctx, service, project, job, dataChan)
 	}
 
 	query := &bigquery.QueryRequest{
-		DefaultDataset: datasetRef,
+		DefaultDataset: defaultDataset(project, dataset),
 		MaxResults:     int64(pageSize),
 		Kind:           "json",
 		Query:          queryStr,
 	}
+	err := opts.applyToRequest(query)
+	if err != nil {
+		if dataChan != nil {
+			dataChan <- Data{Err: err}
+		}
+		return nil, nil, nil, err
+	}
 
-	qr, err := service.Jobs.Query(project, query).Do()
-
-	// extract the initial rows that have already been returned with the Query
-	headers, rows := c.headersAndRows(qr.Schema, qr.Rows)
-
+	ac := c.startCall(ctx, "jobs.query", "project", project, "dataset", dataset)
+	qr, err := service.Jobs.Query(project, query).Context(ac.ctx).Do()
 	if err != nil {
-		c.printDebug("Error loading query: ", err)
+		ac.end(err)
 		if dataChan != nil {
 			dataChan <- Data{Err: err}
 		}
 
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
+	ac.end(nil, "job_id", jobID(qr.JobReference), "rows", len(qr.Rows), "bytes_processed", qr.TotalBytesProcessed)
+	c.metrics().PageFetched(len(qr.Rows))
+
+	// extract the initial rows that have already been returned with the Query
+	headers, rows := c.headersAndRows(ctx, qr.Schema, qr.Rows)
 
-	return c.processPagedQuery(qr.JobReference, qr.PageToken, dataChan, headers, rows)
+	stats := responseStats(qr.JobReference, qr.TotalBytesProcessed, qr.TotalBytesBilled, qr.CacheHit)
+	rows, headers, err = c.processPagedQuery(ctx, qr.JobReference, qr.JobComplete, qr.PageToken, dataChan, qr.Schema, headers, rows, stats)
+	return rows, headers, stats, err
 }
 
 // largeDataPagedQuery builds a job and inserts it into the job queue allowing the flexibility to set the custom AllowLargeResults flag for the job
-func (c *Client) largeDataPagedQuery(service *bigquery.Service, pageSize int, dataset, project, queryStr string, dataChan chan Data) ([][]interface{}, []string, error) {
-	c.printDebug("largeDataPagedQuery starting")
+func (c *Client) largeDataPagedQuery(ctx context.Context, service *bigquery.Service, pageSize int, dataset, project, queryStr string, dataChan chan Data, opts QueryOptions) ([][]interface{}, []string, *QueryStats, error) {
+	c.log().Debug("largeDataPagedQuery starting", "project", project, "dataset", dataset)
 	ts := time.Now()
-	// start query
-	tableRef := bigquery.TableReference{DatasetId: dataset, ProjectId: project, TableId: c.tempTableName}
-	jobConfigQuery := bigquery.JobConfigurationQuery{}
+	// every job gets its own destination so concurrent queries on the client do not overwrite each other's results
+	tableRef, err := c.createTempTable(ctx, project, dataset, nil)
+	if err != nil {
+		if dataChan != nil {
+			dataChan <- Data{Err: err}
+		}
+		return nil, nil, nil, err
+	}
+	// the temp table is dropped even when the query is cancelled, e.g. by closing its RowIterator
+	defer c.dropTempTable(context.WithoutCancel(ctx), tableRef)
 
-	datasetRef := &bigquery.DatasetReference{
-		DatasetId: dataset,
-		ProjectId: project,
+	// start query
+	job, err := newQueryJob(queryStr, project, dataset, tableRef, QueryJobConfig{
+		QueryOptions:      opts,
+		WriteDisposition:  WriteTruncate,
+		CreateDisposition: CreateIfNeeded,
+	})
+	if err != nil {
+		if dataChan != nil {
+			dataChan <- Data{Err: err}
+		}
+		return nil, nil, nil, err
 	}
 
-	jobConfigQuery.AllowLargeResults = true
-	jobConfigQuery.Query = queryStr
-	jobConfigQuery.DestinationTable = &tableRef
-	jobConfigQuery.DefaultDataset = datasetRef
-	if !c.flattenResults {
-		c.printDebug("setting FlattenResults to false")
+	if !c.flattenResults && !opts.standardSQL() {
+		c.log().Debug("setting FlattenResults to false")
 		// need a pointer to bool
 		f := false
-		jobConfigQuery.FlattenResults = &f
+		job.Configuration.Query.FlattenResults = &f
 	}
-	jobConfigQuery.WriteDisposition = "WRITE_TRUNCATE"
-	jobConfigQuery.CreateDisposition = "CREATE_IF_NEEDED"
-
-	jobConfig := bigquery.JobConfiguration{}
 
-	jobConfig.Query = &jobConfigQuery
+	rows, headers, stats, err := c.jobPagedQuery(ctx, service, project, job, dataChan)
+	c.log().Debug("largeDataPagedQuery completed", "project", project, "dataset", dataset, "job_id", jobID(stats.jobReference()), "rows", len(rows), "latency", time.Since(ts))
 
-	job := bigquery.Job{}
-	job.Configuration = &jobConfig
+	return rows, headers, stats, err
+}
 
-	jobInsert := service.Jobs.Insert(project, &job)
+// jobPagedQuery inserts the query job into the job queue and pages over its results
+func (c *Client) jobPagedQuery(ctx context.Context, service *bigquery.Service, project string, job *bigquery.Job, dataChan chan Data) ([][]interface{}, []string, *QueryStats, error) {
+	ac := c.startCall(ctx, "jobs.insert", "project", project)
+	jobInsert := service.Jobs.Insert(project, job).Context(ac.ctx)
 	runningJob, jerr := jobInsert.Do()
-
 	if jerr != nil {
-		c.printDebug("Error inserting job!", jerr)
+		ac.end(jerr)
 		if dataChan != nil {
 			dataChan <- Data{Err: jerr}
 		}
-		return nil, nil, jerr
+		return nil, nil, nil, jerr
 	}
+	ac.end(nil, "job_id", jobID(runningJob.JobReference))
 
 	var qr *bigquery.GetQueryResultsResponse
 	var rows [][]interface{}
@@ -262,163 +395,239 @@ func (c *Client) largeDataPagedQuery(service *bigquery.Service, pageSize int, da
 	// Periodically, job references are not created, but errors are also not thrown.
 	// In this scenario, retry up to 5 times to get a job reference before giving up.
 	for i := 1; ; i++ {
-		r := service.Jobs.GetQueryResults(project, runningJob.JobReference.JobId)
+		ac := c.startCall(ctx, "jobs.getQueryResults", "project", project, "job_id", runningJob.JobReference.JobId, "attempt", i)
+		r := service.Jobs.GetQueryResults(project, runningJob.JobReference.JobId).Context(ac.ctx)
 		r.TimeoutMs(c.RequestTimeout)
+		if len(runningJob.JobReference.Location) > 0 {
+			r.Location(runningJob.JobReference.Location)
+		}
 		qr, err = r.Do()
+		if err != nil {
+			ac.end(err)
+			break
+		}
+		ac.end(nil, "rows", len(qr.Rows), "bytes_processed", qr.TotalBytesProcessed)
+		c.metrics().PageFetched(len(qr.Rows))
 
-		headers, rows = c.headersAndRows(qr.Schema, qr.Rows)
+		headers, rows = c.headersAndRows(ctx, qr.Schema, qr.Rows)
 
-		if i >= maxRequestRetry || qr.JobReference != nil || err != nil {
+		if i >= maxRequestRetry || qr.JobReference != nil {
 			if i > 1 {
-				c.printDebug("Took %v tries to get a job reference", i)
+				c.log().Warn("job reference missing from query results", "job_id", runningJob.JobReference.JobId, "attempts", i)
 			}
 			break
 		}
+		c.metrics().Retried("jobs.getQueryResults", "missing_job_reference")
 	}
 
 	if err == nil && qr.JobReference == nil {
 		err = fmt.Errorf("missing job reference")
+		c.log().Error("error loading query", "job_id", runningJob.JobReference.JobId, "err", err)
 	}
 
 	if err != nil {
-		c.printDebug("Error loading query: ", err)
 		if dataChan != nil {
 			dataChan <- Data{Err: err}
 		}
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	rows, headers, err = c.processPagedQuery(qr.JobReference, qr.PageToken, dataChan, headers, rows)
-	c.printDebug("largeDataPagedQuery completed in ", time.Now().Sub(ts).Seconds(), "s")
-
-	return rows, headers, err
+	stats := responseStats(qr.JobReference, qr.TotalBytesProcessed, 0, qr.CacheHit)
+	rows, headers, err = c.processPagedQuery(ctx, qr.JobReference, qr.JobComplete, qr.PageToken, dataChan, qr.Schema, headers, rows, stats)
+	return rows, headers, stats, err
 }
 
 // pagedQuery executes the query using bq's paging mechanism to load all results and sends them back via dataChan if available, otherwise it returns the full result set, headers and error as return values
-func (c *Client) pagedQuery(pageSize int, dataset, project, queryStr string, dataChan chan Data) ([][]interface{}, []string, error) {
+func (c *Client) pagedQuery(ctx context.Context, pageSize int, dataset, project, queryStr string, dataChan chan Data, opts QueryOptions) (rows [][]interface{}, headers []string, stats *QueryStats, err error) {
+	// the query span covers the job execution, the paging and the decoding of the rows
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "query", "project", project, "dataset", dataset, "large_results", c.allowLargeResults)
+	defer func() {
+		c.recordQuery(project, dataset, opts, start, stats, err)
+		endSpan(span, err, "job_id", jobID(stats.jobReference()), "rows", len(rows))
+		// closed last so that readers waiting for the end of the data know the temp table, if any, has been dropped
+		if dataChan != nil {
+			close(dataChan)
+		}
+	}()
+
 	// connect to service
-	service, err := c.connect()
+	service, err := c.connect(ctx)
 	if err != nil {
 		if dataChan != nil {
 			dataChan <- Data{Err: err}
 		}
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	if c.allowLargeResults && len(c.tempTableName) > 0 {
-		return c.largeDataPagedQuery(service, pageSize, dataset, project, queryStr, dataChan)
+	if c.allowLargeResults {
+		return c.largeDataPagedQuery(ctx, service, pageSize, dataset, project, queryStr, dataChan, opts)
 	}
 
-	return c.stdPagedQuery(service, pageSize, dataset, project, queryStr, dataChan)
+	return c.stdPagedQuery(ctx, service, pageSize, dataset, project, queryStr, dataChan, opts)
 }
 
-func (c *Client) processPagedQuery(jobRef *bigquery.JobReference, pageToken string, dataChan chan Data, headers []string, rows [][]interface{}) ([][]interface{}, []string, error) {
-	resultChan := make(chan [][]interface{})
-	headersChan := make(chan []string)
+// processPagedQuery pages over the remaining results of the job, sending every page over dataChan when set or
+// returning the rows otherwise. Results of a complete job without a page token are already all in rows. The statistics
+// of the later responses update stats. A paging error is sent over dataChan and returned, pagedQuery closes dataChan
+func (c *Client) processPagedQuery(ctx context.Context, jobRef *bigquery.JobReference, jobComplete bool, pageToken string, dataChan chan Data, bqSchema *bigquery.TableSchema, headers []string, rows [][]interface{}, stats *QueryStats) ([][]interface{}, []string, error) {
+	schema := schemaFromBigQuery(bqSchema)
 
-	go c.pageOverJob(len(rows), jobRef, pageToken, resultChan, headersChan)
+	// the rows returned with the query response are the first page
+	rowCount := len(rows)
+	if dataChan != nil && len(rows) > 0 {
+		c.log().Debug("sending rows", "job_id", jobID(jobRef), "rows", len(rows))
+		dataChan <- Data{Headers: headers, Rows: rows, Schema: schema}
+		rows = nil
+	}
 
-L:
-	for {
-		select {
-		case h, ok := <-headersChan:
-			if ok {
-				c.printDebug("got headers")
-				headers = h
-			}
-		case newRows, ok := <-resultChan:
-			if !ok {
-				break L
+	var err error
+	// without a page token getQueryResults would return the first page again
+	if !jobComplete || len(pageToken) > 0 {
+		err = c.pageOverJob(ctx, jobRef, pageToken, rowCount, func(qr *bigquery.GetQueryResultsResponse, pageRows [][]interface{}) {
+			// the schema and statistics are missing from the query response when the job was not complete yet
+			if schema == nil && qr.Schema != nil {
+				schema = schemaFromBigQuery(qr.Schema)
+				headers = schema.names()
 			}
+			stats.TotalBytesProcessed = qr.TotalBytesProcessed
+			stats.CacheHit = qr.CacheHit
+
 			if dataChan != nil {
-				c.printDebug("got rows", len(newRows))
-				dataChan <- Data{Headers: headers, Rows: newRows}
+				c.log().Debug("sending rows", "job_id", jobID(jobRef), "rows", len(pageRows))
+				dataChan <- Data{Headers: headers, Rows: pageRows, Schema: schema}
 			} else {
-				rows = append(rows, newRows...)
+				rows = append(rows, pageRows...)
 			}
+		})
+	}
+	if err != nil {
+		if dataChan != nil {
+			dataChan <- Data{Err: err}
 		}
+		return nil, nil, err
 	}
 
 	if dataChan != nil {
-		close(dataChan)
+		// the results are complete without the job statistics, which only RowIterator.Stats reports
+		jobStats, err := c.jobStats(ctx, jobRef)
+		if err != nil {
+			c.log().Warn("could not load job statistics", "job_id", jobID(jobRef), "err", err)
+		} else {
+			dataChan <- Data{Headers: headers, Schema: schema, Stats: jobStats}
+		}
 	}
 
 	return rows, headers, nil
 }
 
-// pageOverJob loads results for the given job reference and if the total results has not been hit continues to load recursively
-func (c *Client) pageOverJob(rowCount int, jobRef *bigquery.JobReference, pageToken string, resultChan chan [][]interface{}, headersChan chan []string) error {
-	service, err := c.connect()
+// pageOverJob loads the pages of results of the job from pageToken on, passing each one to handle, until rowCount
+// reaches the total number of rows. While the job is not complete it polls with an increasing interval, up to the
+// max wait set with MaxJobWait
+func (c *Client) pageOverJob(ctx context.Context, jobRef *bigquery.JobReference, pageToken string, rowCount int, handle func(*bigquery.GetQueryResultsResponse, [][]interface{})) error {
+	service, err := c.connect(ctx)
 	if err != nil {
 		return err
 	}
 
-	qrc := service.Jobs.GetQueryResults(jobRef.ProjectId, jobRef.JobId)
-	if len(pageToken) > 0 {
-		qrc.PageToken(pageToken)
-	}
+	start := time.Now()
+	interval := minJobPollInterval
+	for page := 1; ; page++ {
+		ac := c.startCall(ctx, "jobs.getQueryResults", "project", jobRef.ProjectId, "job_id", jobRef.JobId, "page", page)
+		qrc := service.Jobs.GetQueryResults(jobRef.ProjectId, jobRef.JobId).Context(ac.ctx)
+		if len(jobRef.Location) > 0 {
+			qrc.Location(jobRef.Location)
+		}
+		if len(pageToken) > 0 {
+			qrc.PageToken(pageToken)
+		}
 
-	qr, err := qrc.Do()
-	if err != nil {
-		c.printDebug("Error loading additional data: ", err)
-		close(resultChan)
-		return err
-	}
+		qr, err := qrc.Do()
+		if err != nil {
+			ac.end(err)
+			return err
+		}
+		ac.end(nil, "job_complete", qr.JobComplete, "rows", len(qr.Rows), "bytes_processed", qr.TotalBytesProcessed)
+		c.metrics().PageFetched(len(qr.Rows))
+
+		if !qr.JobComplete {
+			if c.maxJobWait > 0 && time.Since(start) >= c.maxJobWait {
+				return &JobWaitError{JobID: jobRef.JobId, Waited: time.Since(start)}
+			}
+
+			c.log().Debug("waiting for job results", "job_id", jobRef.JobId, "page", page, "interval", interval)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
 
-	if qr.JobComplete {
-		c.printDebug("qr.JobComplete")
-		headers, rows := c.headersAndRows(qr.Schema, qr.Rows)
-		if headersChan != nil {
-			headersChan <- headers
-			close(headersChan)
+			interval *= 2
+			if interval > maxJobPollInterval {
+				interval = maxJobPollInterval
+			}
+			continue
 		}
 
-		// send back the rows we got
-		c.printDebug("sending rows")
-		resultChan <- rows
-		rowCount = rowCount + len(rows)
-		c.printDebug("Total rows: ", rowCount)
-	}
+		_, rows := c.headersAndRows(ctx, qr.Schema, qr.Rows)
+		rowCount += len(rows)
+		c.log().Debug("loaded rows", "job_id", jobRef.JobId, "page", page, "rows", len(rows), "total_rows", rowCount)
+		handle(qr, rows)
 
-	if qr.TotalRows > uint64(rowCount) || !qr.JobComplete {
-		c.printDebug("!qr.JobComplete")
-		if qr.JobReference == nil {
-			c.pageOverJob(rowCount, jobRef, pageToken, resultChan, headersChan)
-		} else {
-			c.pageOverJob(rowCount, qr.JobReference, qr.PageToken, resultChan, nil)
+		if qr.TotalRows <= uint64(rowCount) || len(qr.PageToken) == 0 {
+			return nil
 		}
-	} else {
-		close(resultChan)
-		return nil
+		if qr.JobReference != nil {
+			jobRef = qr.JobReference
+		}
+		pageToken = qr.PageToken
 	}
-
-	return nil
 }
 
 // SyncQuery executes an arbitrary query string and returns the result synchronously (unless the response takes longer than the provided timeout)
-func (c *Client) SyncQuery(dataset, project, queryStr string, maxResults int64) ([][]interface{}, error) {
-	service, err := c.connect()
+func (c *Client) SyncQuery(dataset, project, queryStr string, maxResults int64, opts ...QueryOptions) ([][]interface{}, error) {
+	return c.SyncQueryContext(context.Background(), dataset, project, queryStr, maxResults, opts...)
+}
+
+// SyncQueryContext is SyncQuery running within ctx, the spans of the query are children of any span in ctx
+func (c *Client) SyncQueryContext(ctx context.Context, dataset, project, queryStr string, maxResults int64, opts ...QueryOptions) ([][]interface{}, error) {
+	queryOpts := c.mergeQueryOptions(opts)
+	start := time.Now()
+	rows, stats, err := c.syncQuery(ctx, dataset, project, queryStr, maxResults, queryOpts)
+	c.recordQuery(project, dataset, queryOpts, start, stats, err)
+	return rows, err
+}
+
+// syncQuery runs the query for SyncQueryContext, returning the first maxResults rows and the statistics of the job
+func (c *Client) syncQuery(ctx context.Context, dataset, project, queryStr string, maxResults int64, queryOpts QueryOptions) ([][]interface{}, *QueryStats, error) {
+	service, err := c.connect(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	datasetRef := &bigquery.DatasetReference{
-		DatasetId: dataset,
-		ProjectId: project,
+	if queryOpts.needsJob() {
+		return c.syncJobQuery(ctx, service, dataset, project, queryStr, maxResults, queryOpts)
 	}
 
 	query := &bigquery.QueryRequest{
-		DefaultDataset: datasetRef,
+		DefaultDataset: defaultDataset(project, dataset),
 		MaxResults:     maxResults,
 		Kind:           "json",
 		Query:          queryStr,
 	}
+	err = queryOpts.applyToRequest(query)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	results, err := service.Jobs.Query(project, query).Do()
+	ac := c.startCall(ctx, "jobs.query", "project", project, "dataset", dataset)
+	results, err := service.Jobs.Query(project, query).Context(ac.ctx).Do()
 	if err != nil {
-		c.printDebug("Query Error: ", err)
-		return nil, err
+		ac.end(err)
+		return nil, nil, err
 	}
+	ac.end(nil, "job_id", jobID(results.JobReference), "rows", len(results.Rows), "bytes_processed", results.TotalBytesProcessed)
+	c.metrics().PageFetched(len(results.Rows))
 
 	// credit to https://github.com/getlantern/statshub for the row building approach
 	numRows := int(results.TotalRows)
@@ -426,19 +635,60 @@ func (c *Client) SyncQuery(dataset, project, queryStr string, maxResults int64)
 		numRows = int(maxResults)
 	}
 
-	_, rows := c.headersAndRows(results.Schema, results.Rows)
-	return rows, nil
+	_, rows := c.headersAndRows(ctx, results.Schema, results.Rows)
+	return rows, responseStats(results.JobReference, results.TotalBytesProcessed, results.TotalBytesBilled, results.CacheHit), nil
+}
+
+// syncJobQuery runs the query as an inserted job for the options jobs.query does not support, waits for it and returns
+// the first maxResults rows
+func (c *Client) syncJobQuery(ctx context.Context, service *bigquery.Service, dataset, project, queryStr string, maxResults int64, opts QueryOptions) ([][]interface{}, *QueryStats, error) {
+	job, err := newQueryJob(queryStr, project, dataset, nil, QueryJobConfig{QueryOptions: opts})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ac := c.startCall(ctx, "jobs.insert", "project", project, "dataset", dataset)
+	job, err = service.Jobs.Insert(project, job).Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		return nil, nil, err
+	}
+	ac.end(nil, "job_id", jobID(job.JobReference))
+
+	done, err := c.waitForJob(ctx, service, job.JobReference)
+	stats := queryStatsFromJob(done)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	ac = c.startCall(ctx, "jobs.getQueryResults", "project", project, "job_id", job.JobReference.JobId)
+	r := service.Jobs.GetQueryResults(project, job.JobReference.JobId).MaxResults(maxResults).Context(ac.ctx)
+	if len(job.JobReference.Location) > 0 {
+		r.Location(job.JobReference.Location)
+	}
+
+	results, err := r.Do()
+	if err != nil {
+		ac.end(err)
+		return nil, stats, err
+	}
+	ac.end(nil, "rows", len(results.Rows))
+	c.metrics().PageFetched(len(results.Rows))
+
+	_, rows := c.headersAndRows(ctx, results.Schema, results.Rows)
+	return rows, stats, nil
 }
 
-func (c *Client) headersAndRows(bqSchema *bigquery.TableSchema, bqRows []*bigquery.TableRow) ([]string, [][]interface{}) {
+func (c *Client) headersAndRows(ctx context.Context, bqSchema *bigquery.TableSchema, bqRows []*bigquery.TableRow) ([]string, [][]interface{}) {
 	if bqSchema == nil || bqRows == nil {
 		return nil, nil
 	}
 
-	c.printDebug("headersAndRows starting")
+	_, span := c.startSpan(ctx, "decode_rows", "rows", len(bqRows), "columns", len(bqSchema.Fields))
+	defer span.End()
+
 	ts := time.Now()
 	headers := make([]string, len(bqSchema.Fields))
-	// c.printDebug("len(bqRows) is", len(bqRows))
 	rows := make([][]interface{}, len(bqRows))
 	// Create headers
 	for i, f := range bqSchema.Fields {
@@ -456,9 +706,9 @@ func (c *Client) headersAndRows(bqSchema *bigquery.TableSchema, bqRows []*bigque
 			}
 		}
 		rows[i] = row
-		// c.printDebug(fmt.Sprintf("built rows[%d] %+v", i, row))
 	}
-	c.printDebug("headersAndRows completed in ", time.Now().Sub(ts).Seconds(), "s")
+	c.log().Debug("built rows", "rows", len(rows), "latency", time.Since(ts))
+	c.metrics().RowsDecoded(len(rows))
 	return headers, rows
 }
 
@@ -470,7 +720,7 @@ func (c *Client) nestedFieldsData(nestedFields []*bigquery.TableFieldSchema, tab
 		data := make(map[string]interface{})
 		vals, ok := tcv["f"]
 		if !ok {
-			c.printDebug("No f key found in nested values!")
+			c.log().Warn("no f key found in nested values")
 		}
 
 		for i, f := range nestedFields {
@@ -490,11 +740,11 @@ func (c *Client) nestedFieldsData(nestedFields []*bigquery.TableFieldSchema, tab
 			d := make(map[string]interface{})
 			mapvv, ok := mapv.(map[string]interface{})["v"]
 			if !ok {
-				c.printDebug("No v key found in nested+repeated values!")
+				c.log().Warn("no v key found in nested repeated values")
 			}
 			vals, ok := mapvv.(map[string]interface{})["f"]
 			if !ok {
-				c.printDebug("No f key found in nested+repeated values!")
+				c.log().Warn("no f key found in nested repeated values")
 			}
 
 			for i, f := range nestedFields {
@@ -510,13 +760,21 @@ func (c *Client) nestedFieldsData(nestedFields []*bigquery.TableFieldSchema, tab
 		}
 		return data
 	default:
-		c.printDebug(fmt.Sprintf("Unexpected type in nestedFieldsdata: %T", tcv))
+		c.log().Warn("unexpected type in nested fields data", "type", fmt.Sprintf("%T", tcv))
 		return nil
 	}
 }
 
-// Count loads the row count for the provided dataset.tablename
+// Count loads the row count for the provided dataset.tablename, or tablename in dataset, reading it from the table
+// metadata when that is exact and falling back to a count(*) query for views and tables with rows still in the streaming buffer
 func (c *Client) Count(dataset, project, datasetTable string) int64 {
+	if projectID, datasetID, tableID, ok := splitTableName(project, dataset, datasetTable); ok {
+		md, err := c.GetTable(context.Background(), projectID, datasetID, tableID)
+		if err == nil && md.Type == TableTypeTable && md.StreamingBuffer == nil {
+			return int64(md.NumRows)
+		}
+	}
+
 	qstr := fmt.Sprintf("select count(*) from [%s]", datasetTable)
 	res, err := c.SyncQuery(dataset, project, qstr, 1)
 	if err == nil {
@@ -528,8 +786,11 @@ func (c *Client) Count(dataset, project, datasetTable string) int64 {
 	return 0
 }
 
-func (c *Client) printDebug(v ...interface{}) {
-	if c.PrintDebug {
-		fmt.Println(v)
+// defaultDataset returns the default dataset of the queries, nil when dataset is empty so that queries only use fully
+// qualified table names
+func defaultDataset(project, dataset string) *bigquery.DatasetReference {
+	if len(dataset) == 0 {
+		return nil
 	}
+	return &bigquery.DatasetReference{DatasetId: dataset, ProjectId: project}
 }
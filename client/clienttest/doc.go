@@ -0,0 +1,2 @@
+// Package clienttest provides helpers to test code using the bigquery client without a bigquery project
+package clienttest
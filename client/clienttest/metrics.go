@@ -0,0 +1,159 @@
+package clienttest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+// Metrics is an in-memory client.MetricsRecorder, pass it to client.WithMetrics and assert on the values it recorded
+type Metrics struct {
+	mu sync.Mutex
+
+	queries        []client.QueryMetrics
+	pages          int
+	rowsDecoded    int
+	insertRows     map[string]int // keyed by project:dataset.table
+	insertBytes    map[string]int // keyed by project:dataset.table
+	insertFailures map[string]int // keyed by reason
+	retries        map[string]int // keyed by call/reason
+}
+
+var _ client.MetricsRecorder = (*Metrics)(nil)
+
+// NewMetrics creates an empty recorder
+func NewMetrics() *Metrics {
+	m := &Metrics{}
+	m.Reset()
+	return m
+}
+
+// Reset discards every recorded value
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queries = nil
+	m.pages = 0
+	m.rowsDecoded = 0
+	m.insertRows = map[string]int{}
+	m.insertBytes = map[string]int{}
+	m.insertFailures = map[string]int{}
+	m.retries = map[string]int{}
+}
+
+// Queries returns the queries recorded, in completion order
+func (m *Metrics) Queries() []client.QueryMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]client.QueryMetrics(nil), m.queries...)
+}
+
+// BytesBilled returns the total bytes billed for the recorded queries
+func (m *Metrics) BytesBilled() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total int64
+	for _, q := range m.queries {
+		total += q.BytesBilled
+	}
+	return total
+}
+
+// BytesProcessed returns the total bytes processed by the recorded queries
+func (m *Metrics) BytesProcessed() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total int64
+	for _, q := range m.queries {
+		total += q.BytesProcessed
+	}
+	return total
+}
+
+// Pages returns the number of result pages fetched
+func (m *Metrics) Pages() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pages
+}
+
+// DecodedRows returns the number of result rows decoded
+func (m *Metrics) DecodedRows() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rowsDecoded
+}
+
+// Inserted returns the rows accepted by streaming inserts into the table and the bytes sent
+func (m *Metrics) Inserted(projectID, datasetID, tableID string) (rows, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := tableKey(projectID, datasetID, tableID)
+	return m.insertRows[key], m.insertBytes[key]
+}
+
+// InsertFailures returns the rows rejected by streaming inserts for reason, across all tables
+func (m *Metrics) InsertFailures(reason string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.insertFailures[reason]
+}
+
+// Retries returns the number of times call was retried because of reason
+func (m *Metrics) Retries(call, reason string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.retries[call+"/"+reason]
+}
+
+// QueryCompleted implements client.MetricsRecorder
+func (m *Metrics) QueryCompleted(q client.QueryMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queries = append(m.queries, q)
+}
+
+// PageFetched implements client.MetricsRecorder
+func (m *Metrics) PageFetched(rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pages++
+}
+
+// RowsDecoded implements client.MetricsRecorder
+func (m *Metrics) RowsDecoded(rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rowsDecoded += rows
+}
+
+// RowsInserted implements client.MetricsRecorder
+func (m *Metrics) RowsInserted(projectID, datasetID, tableID string, rows, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := tableKey(projectID, datasetID, tableID)
+	m.insertRows[key] += rows
+	m.insertBytes[key] += bytes
+}
+
+// InsertFailed implements client.MetricsRecorder
+func (m *Metrics) InsertFailed(projectID, datasetID, tableID, reason string, rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.insertFailures[reason] += rows
+}
+
+// Retried implements client.MetricsRecorder
+func (m *Metrics) Retried(call, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[call+"/"+reason]++
+}
+
+func tableKey(projectID, datasetID, tableID string) string {
+	return fmt.Sprintf("%s:%s.%s", projectID, datasetID, tableID)
+}
@@ -0,0 +1,351 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+// ErrNotStubbed is wrapped by the errors returned by the methods of a Mock whose func is not set
+var ErrNotStubbed = errors.New("not stubbed")
+
+// Call is a method call received by a Mock
+type Call struct {
+	Method string
+	Args   []interface{} // the arguments of the call, ctx and options included
+}
+
+// Mock implements client.Querier, client.Inserter and client.TableAdmin with the functions set in its fields, so that
+// code using the client can be unit tested. Methods without a context call the func of their context variant with
+// context.Background(), e.g. Query calls QueryFunc. A method whose func is not set returns zero values and an error
+// wrapping ErrNotStubbed, except Count which returns 0. Every call is recorded and can be inspected with Calls
+type Mock struct {
+	QueryFunc           func(ctx context.Context, dataset, project, queryStr string, opts []client.QueryOptions) ([][]interface{}, []string, error)
+	AsyncQueryFunc      func(ctx context.Context, pageSize int, dataset, project, queryStr string, dataChan chan client.Data, opts []client.QueryOptions)
+	SyncQueryFunc       func(ctx context.Context, dataset, project, queryStr string, maxResults int64, opts []client.QueryOptions) ([][]interface{}, error)
+	QueryWithStatsFunc  func(ctx context.Context, dataset, project, queryStr string, opts []client.QueryOptions) ([][]interface{}, []string, *client.QueryStats, error)
+	ExecFunc            func(ctx context.Context, dataset, project, queryStr string, params []interface{}) (*client.ExecResult, error)
+	ExecWithOptionsFunc func(ctx context.Context, dataset, project, queryStr string, opts client.QueryOptions) (*client.ExecResult, error)
+	QueryToTableFunc    func(ctx context.Context, queryStr, projectID, datasetID, tableID string, config client.QueryJobConfig) (*client.QueryStats, error)
+	DryRunFunc          func(ctx context.Context, dataset, project, queryStr string, opts []client.QueryOptions) (*client.QueryStats, error)
+	CountFunc           func(dataset, project, datasetTable string) int64
+
+	InsertRowsFunc func(ctx context.Context, projectID, datasetID, tableID string, rows []map[string]interface{}) error
+	UpsertFunc     func(ctx context.Context, projectID, datasetID, tableID string, keyColumns []string, rows []map[string]interface{}) (*client.ExecResult, error)
+
+	InsertNewTableFunc               func(projectID, datasetID, tableName string, fields map[string]string, options []client.TableOption) error
+	InsertNewTableIfDoesNotExistFunc func(projectID, datasetID, tableID string, fields map[string]string, options []client.TableOption) error
+	PatchTableSchemaFunc             func(projectID, datasetID, tableID string, fields map[string]string) error
+	EvolveSchemaFunc                 func(ctx context.Context, projectID, datasetID, tableID string, desired client.Schema, policy client.SchemaPolicy) ([]client.SchemaChange, error)
+	CheckTableFunc                   func(ctx context.Context, projectID, datasetID, tableID string, desired client.Schema) error
+	GetTableFunc                     func(ctx context.Context, projectID, datasetID, tableID string) (*client.TableMetadata, error)
+	UpdateTableFunc                  func(ctx context.Context, projectID, datasetID, tableID string, update client.TableUpdate, etag string) (*client.TableMetadata, error)
+	DeleteTableFunc                  func(ctx context.Context, projectID, datasetID, tableID string, ignoreNotFound bool) error
+	CreateViewFunc                   func(ctx context.Context, projectID, datasetID, viewID, query string, opts client.ViewOptions) error
+	CreateMaterializedViewFunc       func(ctx context.Context, projectID, datasetID, viewID, query string, opts client.MaterializedViewOptions, options []client.TableOption) error
+	UpdateViewQueryFunc              func(ctx context.Context, projectID, datasetID, viewID, query string) error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var (
+	_ client.Querier    = (*Mock)(nil)
+	_ client.Inserter   = (*Mock)(nil)
+	_ client.TableAdmin = (*Mock)(nil)
+)
+
+// Calls returns the calls received by the method, or every call received if method is empty, in order
+func (m *Mock) Calls(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []Call
+	for _, c := range m.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the calls received, the funcs are kept
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func notStubbed(method string) error {
+	return fmt.Errorf("Mock.%s: %w", method, ErrNotStubbed)
+}
+
+func (m *Mock) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+// Query implements client.Querier
+func (m *Mock) Query(dataset, project, queryStr string, opts ...client.QueryOptions) ([][]interface{}, []string, error) {
+	return m.QueryContext(context.Background(), dataset, project, queryStr, opts...)
+}
+
+// QueryContext implements client.Querier
+func (m *Mock) QueryContext(ctx context.Context, dataset, project, queryStr string, opts ...client.QueryOptions) ([][]interface{}, []string, error) {
+	m.record("QueryContext", ctx, dataset, project, queryStr, opts)
+	if m.QueryFunc == nil {
+		return nil, nil, notStubbed("QueryContext")
+	}
+	return m.QueryFunc(ctx, dataset, project, queryStr, opts)
+}
+
+// AsyncQuery implements client.Querier
+func (m *Mock) AsyncQuery(pageSize int, dataset, project, queryStr string, dataChan chan client.Data, opts ...client.QueryOptions) {
+	m.AsyncQueryContext(context.Background(), pageSize, dataset, project, queryStr, dataChan, opts...)
+}
+
+// AsyncQueryContext implements client.Querier. Without AsyncQueryFunc the rows returned by QueryFunc are sent in pages
+// of pageSize rows, or its error is sent, before the channel is closed like the client does
+func (m *Mock) AsyncQueryContext(ctx context.Context, pageSize int, dataset, project, queryStr string, dataChan chan client.Data, opts ...client.QueryOptions) {
+	m.record("AsyncQueryContext", ctx, pageSize, dataset, project, queryStr, dataChan, opts)
+	if m.AsyncQueryFunc != nil {
+		m.AsyncQueryFunc(ctx, pageSize, dataset, project, queryStr, dataChan, opts)
+		return
+	}
+
+	err := notStubbed("AsyncQueryContext")
+	var rows [][]interface{}
+	var headers []string
+	if m.QueryFunc != nil {
+		rows, headers, err = m.QueryFunc(ctx, dataset, project, queryStr, opts)
+	}
+	if err != nil {
+		dataChan <- client.Data{Err: err}
+		close(dataChan)
+		return
+	}
+
+	if pageSize <= 0 {
+		pageSize = len(rows)
+	}
+	for len(rows) > 0 {
+		n := pageSize
+		if n > len(rows) {
+			n = len(rows)
+		}
+		dataChan <- client.Data{Headers: headers, Rows: rows[:n]}
+		rows = rows[n:]
+	}
+	close(dataChan)
+}
+
+// Rows implements client.Querier, the iterator reads the pages sent by AsyncQueryContext
+func (m *Mock) Rows(ctx context.Context, pageSize int, dataset, project, queryStr string, opts ...client.QueryOptions) *client.RowIterator {
+	dataChan := make(chan client.Data)
+	go m.AsyncQueryContext(ctx, pageSize, dataset, project, queryStr, dataChan, opts...)
+	return client.NewRowIterator(dataChan)
+}
+
+// SyncQuery implements client.Querier
+func (m *Mock) SyncQuery(dataset, project, queryStr string, maxResults int64, opts ...client.QueryOptions) ([][]interface{}, error) {
+	return m.SyncQueryContext(context.Background(), dataset, project, queryStr, maxResults, opts...)
+}
+
+// SyncQueryContext implements client.Querier
+func (m *Mock) SyncQueryContext(ctx context.Context, dataset, project, queryStr string, maxResults int64, opts ...client.QueryOptions) ([][]interface{}, error) {
+	m.record("SyncQueryContext", ctx, dataset, project, queryStr, maxResults, opts)
+	if m.SyncQueryFunc == nil {
+		return nil, notStubbed("SyncQueryContext")
+	}
+	return m.SyncQueryFunc(ctx, dataset, project, queryStr, maxResults, opts)
+}
+
+// QueryWithStats implements client.Querier
+func (m *Mock) QueryWithStats(dataset, project, queryStr string, opts ...client.QueryOptions) ([][]interface{}, []string, *client.QueryStats, error) {
+	return m.QueryWithStatsContext(context.Background(), dataset, project, queryStr, opts...)
+}
+
+// QueryWithStatsContext implements client.Querier
+func (m *Mock) QueryWithStatsContext(ctx context.Context, dataset, project, queryStr string, opts ...client.QueryOptions) ([][]interface{}, []string, *client.QueryStats, error) {
+	m.record("QueryWithStatsContext", ctx, dataset, project, queryStr, opts)
+	if m.QueryWithStatsFunc == nil {
+		return nil, nil, nil, notStubbed("QueryWithStatsContext")
+	}
+	return m.QueryWithStatsFunc(ctx, dataset, project, queryStr, opts)
+}
+
+// Exec implements client.Querier
+func (m *Mock) Exec(ctx context.Context, dataset, project, queryStr string, params ...interface{}) (*client.ExecResult, error) {
+	m.record("Exec", ctx, dataset, project, queryStr, params)
+	if m.ExecFunc == nil {
+		return nil, notStubbed("Exec")
+	}
+	return m.ExecFunc(ctx, dataset, project, queryStr, params)
+}
+
+// ExecWithOptions implements client.Querier
+func (m *Mock) ExecWithOptions(ctx context.Context, dataset, project, queryStr string, opts client.QueryOptions) (*client.ExecResult, error) {
+	m.record("ExecWithOptions", ctx, dataset, project, queryStr, opts)
+	if m.ExecWithOptionsFunc == nil {
+		return nil, notStubbed("ExecWithOptions")
+	}
+	return m.ExecWithOptionsFunc(ctx, dataset, project, queryStr, opts)
+}
+
+// QueryToTable implements client.Querier
+func (m *Mock) QueryToTable(ctx context.Context, queryStr, projectID, datasetID, tableID string, config client.QueryJobConfig) (*client.QueryStats, error) {
+	m.record("QueryToTable", ctx, queryStr, projectID, datasetID, tableID, config)
+	if m.QueryToTableFunc == nil {
+		return nil, notStubbed("QueryToTable")
+	}
+	return m.QueryToTableFunc(ctx, queryStr, projectID, datasetID, tableID, config)
+}
+
+// DryRun implements client.Querier
+func (m *Mock) DryRun(ctx context.Context, dataset, project, queryStr string, opts ...client.QueryOptions) (*client.QueryStats, error) {
+	m.record("DryRun", ctx, dataset, project, queryStr, opts)
+	if m.DryRunFunc == nil {
+		return nil, notStubbed("DryRun")
+	}
+	return m.DryRunFunc(ctx, dataset, project, queryStr, opts)
+}
+
+// Count implements client.Querier
+func (m *Mock) Count(dataset, project, datasetTable string) int64 {
+	m.record("Count", dataset, project, datasetTable)
+	if m.CountFunc == nil {
+		return 0
+	}
+	return m.CountFunc(dataset, project, datasetTable)
+}
+
+// InsertRow implements client.Inserter, calling InsertRowsFunc with the single row
+func (m *Mock) InsertRow(projectID, datasetID, tableID string, rowData map[string]interface{}) error {
+	return m.InsertRowsContext(context.Background(), projectID, datasetID, tableID, []map[string]interface{}{rowData})
+}
+
+// InsertRows implements client.Inserter
+func (m *Mock) InsertRows(projectID, datasetID, tableID string, rows []map[string]interface{}) error {
+	return m.InsertRowsContext(context.Background(), projectID, datasetID, tableID, rows)
+}
+
+// InsertRowsContext implements client.Inserter
+func (m *Mock) InsertRowsContext(ctx context.Context, projectID, datasetID, tableID string, rows []map[string]interface{}) error {
+	m.record("InsertRowsContext", ctx, projectID, datasetID, tableID, rows)
+	if m.InsertRowsFunc == nil {
+		return notStubbed("InsertRowsContext")
+	}
+	return m.InsertRowsFunc(ctx, projectID, datasetID, tableID, rows)
+}
+
+// Upsert implements client.Inserter
+func (m *Mock) Upsert(ctx context.Context, projectID, datasetID, tableID string, keyColumns []string, rows []map[string]interface{}) (*client.ExecResult, error) {
+	m.record("Upsert", ctx, projectID, datasetID, tableID, keyColumns, rows)
+	if m.UpsertFunc == nil {
+		return nil, notStubbed("Upsert")
+	}
+	return m.UpsertFunc(ctx, projectID, datasetID, tableID, keyColumns, rows)
+}
+
+// InsertNewTable implements client.TableAdmin
+func (m *Mock) InsertNewTable(projectID, datasetID, tableName string, fields map[string]string, options ...client.TableOption) error {
+	m.record("InsertNewTable", projectID, datasetID, tableName, fields, options)
+	if m.InsertNewTableFunc == nil {
+		return notStubbed("InsertNewTable")
+	}
+	return m.InsertNewTableFunc(projectID, datasetID, tableName, fields, options)
+}
+
+// InsertNewTableIfDoesNotExist implements client.TableAdmin
+func (m *Mock) InsertNewTableIfDoesNotExist(projectID, datasetID, tableID string, fields map[string]string, options ...client.TableOption) error {
+	m.record("InsertNewTableIfDoesNotExist", projectID, datasetID, tableID, fields, options)
+	if m.InsertNewTableIfDoesNotExistFunc == nil {
+		return notStubbed("InsertNewTableIfDoesNotExist")
+	}
+	return m.InsertNewTableIfDoesNotExistFunc(projectID, datasetID, tableID, fields, options)
+}
+
+// PatchTableSchema implements client.TableAdmin
+func (m *Mock) PatchTableSchema(projectID, datasetID, tableID string, fields map[string]string) error {
+	m.record("PatchTableSchema", projectID, datasetID, tableID, fields)
+	if m.PatchTableSchemaFunc == nil {
+		return notStubbed("PatchTableSchema")
+	}
+	return m.PatchTableSchemaFunc(projectID, datasetID, tableID, fields)
+}
+
+// EvolveSchema implements client.TableAdmin
+func (m *Mock) EvolveSchema(ctx context.Context, projectID, datasetID, tableID string, desired client.Schema, policy client.SchemaPolicy) ([]client.SchemaChange, error) {
+	m.record("EvolveSchema", ctx, projectID, datasetID, tableID, desired, policy)
+	if m.EvolveSchemaFunc == nil {
+		return nil, notStubbed("EvolveSchema")
+	}
+	return m.EvolveSchemaFunc(ctx, projectID, datasetID, tableID, desired, policy)
+}
+
+// CheckTable implements client.TableAdmin
+func (m *Mock) CheckTable(ctx context.Context, projectID, datasetID, tableID string, desired client.Schema) error {
+	m.record("CheckTable", ctx, projectID, datasetID, tableID, desired)
+	if m.CheckTableFunc == nil {
+		return notStubbed("CheckTable")
+	}
+	return m.CheckTableFunc(ctx, projectID, datasetID, tableID, desired)
+}
+
+// GetTable implements client.TableAdmin
+func (m *Mock) GetTable(ctx context.Context, projectID, datasetID, tableID string) (*client.TableMetadata, error) {
+	m.record("GetTable", ctx, projectID, datasetID, tableID)
+	if m.GetTableFunc == nil {
+		return nil, notStubbed("GetTable")
+	}
+	return m.GetTableFunc(ctx, projectID, datasetID, tableID)
+}
+
+// UpdateTable implements client.TableAdmin
+func (m *Mock) UpdateTable(ctx context.Context, projectID, datasetID, tableID string, update client.TableUpdate, etag string) (*client.TableMetadata, error) {
+	m.record("UpdateTable", ctx, projectID, datasetID, tableID, update, etag)
+	if m.UpdateTableFunc == nil {
+		return nil, notStubbed("UpdateTable")
+	}
+	return m.UpdateTableFunc(ctx, projectID, datasetID, tableID, update, etag)
+}
+
+// DeleteTable implements client.TableAdmin
+func (m *Mock) DeleteTable(ctx context.Context, projectID, datasetID, tableID string, ignoreNotFound bool) error {
+	m.record("DeleteTable", ctx, projectID, datasetID, tableID, ignoreNotFound)
+	if m.DeleteTableFunc == nil {
+		return notStubbed("DeleteTable")
+	}
+	return m.DeleteTableFunc(ctx, projectID, datasetID, tableID, ignoreNotFound)
+}
+
+// CreateView implements client.TableAdmin
+func (m *Mock) CreateView(ctx context.Context, projectID, datasetID, viewID, query string, opts client.ViewOptions) error {
+	m.record("CreateView", ctx, projectID, datasetID, viewID, query, opts)
+	if m.CreateViewFunc == nil {
+		return notStubbed("CreateView")
+	}
+	return m.CreateViewFunc(ctx, projectID, datasetID, viewID, query, opts)
+}
+
+// CreateMaterializedView implements client.TableAdmin
+func (m *Mock) CreateMaterializedView(ctx context.Context, projectID, datasetID, viewID, query string, opts client.MaterializedViewOptions, options ...client.TableOption) error {
+	m.record("CreateMaterializedView", ctx, projectID, datasetID, viewID, query, opts, options)
+	if m.CreateMaterializedViewFunc == nil {
+		return notStubbed("CreateMaterializedView")
+	}
+	return m.CreateMaterializedViewFunc(ctx, projectID, datasetID, viewID, query, opts, options)
+}
+
+// UpdateViewQuery implements client.TableAdmin
+func (m *Mock) UpdateViewQuery(ctx context.Context, projectID, datasetID, viewID, query string) error {
+	m.record("UpdateViewQuery", ctx, projectID, datasetID, viewID, query)
+	if m.UpdateViewQueryFunc == nil {
+		return notStubbed("UpdateViewQuery")
+	}
+	return m.UpdateViewQueryFunc(ctx, projectID, datasetID, viewID, query)
+}
@@ -0,0 +1,119 @@
+package clienttest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+	"google.golang.org/api/iterator"
+)
+
+func TestMockStubbed(t *testing.T) {
+	m := &clienttest.Mock{
+		GetTableFunc: func(ctx context.Context, projectID, datasetID, tableID string) (*client.TableMetadata, error) {
+			return &client.TableMetadata{Etag: "e1"}, nil
+		},
+		InsertRowsFunc: func(ctx context.Context, projectID, datasetID, tableID string, rows []map[string]interface{}) error {
+			if len(rows) != 1 || rows[0]["a"] != 1 {
+				t.Errorf("rows = %v, want the single row", rows)
+			}
+			return nil
+		},
+	}
+
+	md, err := m.GetTable(context.Background(), "proj", "ds", "events")
+	if err != nil || md.Etag != "e1" {
+		t.Errorf("GetTable = %+v, %v", md, err)
+	}
+	if err = m.InsertRow("proj", "ds", "events", map[string]interface{}{"a": 1}); err != nil {
+		t.Error(err)
+	}
+
+	calls := m.Calls("GetTable")
+	if len(calls) != 1 || !reflect.DeepEqual(calls[0].Args[1:], []interface{}{"proj", "ds", "events"}) {
+		t.Errorf("GetTable calls = %+v", calls)
+	}
+	if n := len(m.Calls("")); n != 2 {
+		t.Errorf("%d calls recorded, want 2", n)
+	}
+
+	m.Reset()
+	if n := len(m.Calls("")); n != 0 {
+		t.Errorf("%d calls recorded after Reset", n)
+	}
+}
+
+func TestMockNotStubbed(t *testing.T) {
+	m := &clienttest.Mock{}
+	ctx := context.Background()
+
+	if _, err := m.GetTable(ctx, "proj", "ds", "events"); !errors.Is(err, clienttest.ErrNotStubbed) {
+		t.Errorf("GetTable err = %v, want ErrNotStubbed", err)
+	}
+	if _, _, err := m.Query("ds", "proj", "SELECT 1"); !errors.Is(err, clienttest.ErrNotStubbed) {
+		t.Errorf("Query err = %v, want ErrNotStubbed", err)
+	}
+	if err := m.DeleteTable(ctx, "proj", "ds", "events", false); !errors.Is(err, clienttest.ErrNotStubbed) {
+		t.Errorf("DeleteTable err = %v, want ErrNotStubbed", err)
+	}
+	if _, err := m.Rows(ctx, 10, "ds", "proj", "SELECT 1").NextPage(); !errors.Is(err, clienttest.ErrNotStubbed) {
+		t.Errorf("Rows err = %v, want ErrNotStubbed", err)
+	}
+	if n := m.Count("ds", "proj", "events"); n != 0 {
+		t.Errorf("Count = %d, want 0", n)
+	}
+}
+
+func TestMockRows(t *testing.T) {
+	m := &clienttest.Mock{
+		QueryFunc: func(ctx context.Context, dataset, project, queryStr string, opts []client.QueryOptions) ([][]interface{}, []string, error) {
+			return [][]interface{}{{"a"}, {"b"}, {"c"}}, []string{"x"}, nil
+		},
+	}
+
+	it := m.Rows(context.Background(), 2, "ds", "proj", "SELECT x FROM t")
+	defer it.Close()
+
+	var pages [][][]interface{}
+	for {
+		rows, err := it.NextPage()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, rows)
+	}
+
+	want := [][][]interface{}{{{"a"}, {"b"}}, {{"c"}}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+	if !reflect.DeepEqual(it.Headers(), []string{"x"}) {
+		t.Errorf("headers = %v", it.Headers())
+	}
+}
+
+func TestMockAsyncQueryError(t *testing.T) {
+	failed := errors.New("failed")
+	m := &clienttest.Mock{
+		QueryFunc: func(ctx context.Context, dataset, project, queryStr string, opts []client.QueryOptions) ([][]interface{}, []string, error) {
+			return nil, nil, failed
+		},
+	}
+
+	dataChan := make(chan client.Data)
+	go m.AsyncQuery(10, "ds", "proj", "SELECT 1", dataChan)
+
+	var errs []error
+	for d := range dataChan {
+		errs = append(errs, d.Err)
+	}
+	if len(errs) != 1 || errs[0] != failed {
+		t.Errorf("errors = %v, want the query error before the channel is closed", errs)
+	}
+}
@@ -0,0 +1,244 @@
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode selects whether a Recorder records real API traffic or replays it
+type Mode int
+
+// The modes of a Recorder
+const (
+	// Replay serves the responses saved in the golden file without sending any request
+	Replay Mode = iota
+	// Record sends the requests to the API and saves them with their responses to the golden file on Close
+	Record
+)
+
+// scrubbedHeaders are removed from the saved interactions so that golden files never contain credentials
+var scrubbedHeaders = []string{"Authorization", "X-Goog-Api-Key", "Cookie", "Set-Cookie", "X-Goog-User-Project"}
+
+// Interaction is a request and its response as saved in a golden file
+type Interaction struct {
+	Method          string          `json:"method"`
+	URL             string          `json:"url"`
+	RequestHeaders  http.Header     `json:"request_headers,omitempty"`
+	RequestBody     json.RawMessage `json:"request_body,omitempty"`
+	RawRequestBody  string          `json:"raw_request_body,omitempty"` // set instead of RequestBody when not JSON
+	StatusCode      int             `json:"status_code"`
+	ResponseHeaders http.Header     `json:"response_headers,omitempty"`
+	ResponseBody    json.RawMessage `json:"response_body,omitempty"`
+	RawResponseBody string          `json:"raw_response_body,omitempty"` // set instead of ResponseBody when not JSON
+
+	used bool
+}
+
+// Recorder is an http.RoundTripper recording BigQuery API requests and responses to a golden file, or replaying them
+// from it so that tests run offline and deterministically. Pass Client to client.WithHTTPClient:
+//
+//	rec, err := clienttest.NewRecorder("testdata/nested_query.json", clienttest.Replay, nil)
+//	...
+//	defer rec.Close()
+//	bq := client.New("", client.WithHTTPClient(rec.Client()))
+//
+// When replaying, a request is served the first unused interaction with the same HTTP method and URL path, so pages
+// are replayed in the order they were recorded. Requests naming resources generated by the client, such as the temp
+// tables of AllowLargeResults, can not be replayed
+type Recorder struct {
+	path string
+	mode Mode
+	base http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+// NewRecorder creates a recorder for the golden file at path. In Record mode requests are sent with base, which must
+// authorize them, e.g. the transport of the client returned by google.DefaultClient. In Replay mode base is unused and
+// the golden file must exist
+func NewRecorder(path string, mode Mode, base http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, base: base}
+
+	switch mode {
+	case Record:
+		if base == nil {
+			return nil, fmt.Errorf("recording %s requires an authorized transport", path)
+		}
+	case Replay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("invalid golden file %s: %v", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %d", mode)
+	}
+
+	return r, nil
+}
+
+// Client returns an HTTP client sending its requests through the recorder
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the interactions recorded or loaded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := make([]Interaction, len(r.interactions))
+	for i, in := range r.interactions {
+		interactions[i] = *in
+	}
+	return interactions
+}
+
+// Close writes the golden file in Record mode, creating its directory if needed. In Replay mode it returns an error
+// if some interactions were not replayed, which usually means the code under test changed
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == Replay {
+		unused := 0
+		for _, in := range r.interactions {
+			if !in.used {
+				unused++
+			}
+		}
+		if unused > 0 {
+			return fmt.Errorf("%d of the %d interactions in %s were not replayed", unused, len(r.interactions), r.path)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == Replay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	in := &Interaction{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: scrub(req.Header),
+	}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		in.RequestBody, in.RawRequestBody = splitBody(body)
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	in.StatusCode = resp.StatusCode
+	in.ResponseHeaders = scrub(resp.Header)
+	in.ResponseBody, in.RawResponseBody = splitBody(body)
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, in := range r.interactions {
+		if in.used || in.Method != req.Method {
+			continue
+		}
+
+		recorded, err := req.URL.Parse(in.URL)
+		if err != nil || recorded.Path != req.URL.Path {
+			continue
+		}
+
+		in.used = true
+		body := []byte(in.RawResponseBody)
+		if len(in.ResponseBody) > 0 {
+			body = in.ResponseBody
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+			StatusCode:    in.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.ResponseHeaders.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded response in %s for %s %s", r.path, req.Method, req.URL)
+}
+
+// scrub returns a copy of the headers without credentials
+func scrub(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range scrubbedHeaders {
+		h.Del(k)
+	}
+	return h
+}
+
+// splitBody returns body as JSON when it is valid JSON, or as text otherwise
+func splitBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body), ""
+	}
+	return nil, string(body)
+}
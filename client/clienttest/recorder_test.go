@@ -0,0 +1,117 @@
+package clienttest_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+)
+
+// roundTripFunc is a fake API answering every request with f
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+const queryResponse = `{"kind": "bigquery#queryResponse", "jobReference": {"projectId": "proj", "jobId": "job_1"},
+	"schema": {"fields": [{"name": "a", "type": "STRING"}]}, "rows": [{"f": [{"v": "x"}]}, {"f": [{"v": "y"}]}],
+	"totalRows": "2", "jobComplete": true}`
+
+func TestRecordReplay(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "testdata", "query.json")
+
+	api := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("the request sent to the API is not authorized")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}, "Set-Cookie": []string{"session=secret"}},
+			Body:       io.NopCloser(strings.NewReader(queryResponse)),
+		}, nil
+	})
+	rec, err := clienttest.NewRecorder(golden, clienttest.Record, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorize := func(method string, req *http.Request, next client.Invoker) (*http.Response, error) {
+		req.Header.Set("Authorization", "Bearer secret")
+		return next(req)
+	}
+	bq := client.New("", client.WithHTTPClient(rec.Client()), client.WithInterceptors(authorize))
+	recorded, headers, err := bq.Query("ds", "proj", "SELECT a FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("credentials saved in the golden file:\n%s", data)
+	}
+
+	rec, err = clienttest.NewRecorder(golden, clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bq = client.New("", client.WithHTTPClient(rec.Client()))
+	replayed, replayedHeaders, err := bq.Query("ds", "proj", "SELECT a FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rec.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if !reflect.DeepEqual(replayed, recorded) || !reflect.DeepEqual(replayedHeaders, headers) {
+		t.Errorf("replayed %v %v, recorded %v %v", replayedHeaders, replayed, headers, recorded)
+	}
+	if want := [][]interface{}{{"x"}, {"y"}}; !reflect.DeepEqual(recorded, want) {
+		t.Errorf("rows = %v, want %v", recorded, want)
+	}
+}
+
+func TestRecordRequiresTransport(t *testing.T) {
+	if _, err := clienttest.NewRecorder(filepath.Join(t.TempDir(), "query.json"), clienttest.Record, nil); err == nil {
+		t.Error("recording without a transport succeeded")
+	}
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "query.json")
+	interactions := `[{"method": "POST", "url": "https://bigquery.googleapis.com/bigquery/v2/projects/proj/queries",
+		"status_code": 200, "response_body": ` + queryResponse + `}]`
+	if err := os.WriteFile(golden, []byte(interactions), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := clienttest.NewRecorder(golden, clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bq := client.New("", client.WithHTTPClient(rec.Client()))
+	_, err = bq.GetTable(context.Background(), "proj", "ds", "events")
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("err = %v, want no recorded response", err)
+	}
+
+	// the recorded query was never replayed
+	if err = rec.Close(); err == nil || !strings.Contains(err.Error(), "1 of the 1 interactions") {
+		t.Errorf("Close err = %v, want the unused interaction reported", err)
+	}
+}
@@ -0,0 +1,339 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/iterator"
+)
+
+// DatasetMetadata is the metadata of a dataset as returned by GetDataset
+type DatasetMetadata struct {
+	ProjectID    string
+	DatasetID    string
+	FriendlyName string
+	Description  string
+	Location     string
+	Labels       map[string]string
+	Etag         string // pass to UpdateDataset to detect concurrent modifications
+	Access       []AccessEntry
+
+	DefaultTableExpiration     time.Duration // zero if tables never expire by default
+	DefaultPartitionExpiration time.Duration // zero if partitions never expire by default
+
+	CreationTime     time.Time
+	LastModifiedTime time.Time
+}
+
+// DatasetOption is a configuration function applied to a dataset before it is created
+type DatasetOption func(*bigquery.Dataset) error
+
+// DatasetLocation is a dataset option setting the location (e.g. US, EU or a region) the dataset is stored in
+func DatasetLocation(location string) DatasetOption {
+	return func(d *bigquery.Dataset) error {
+		d.Location = location
+		return nil
+	}
+}
+
+// DatasetDescription is a dataset option setting the description of the dataset
+func DatasetDescription(description string) DatasetOption {
+	return func(d *bigquery.Dataset) error {
+		d.Description = description
+		return nil
+	}
+}
+
+// DatasetLabels is a dataset option setting the labels of the dataset
+func DatasetLabels(labels map[string]string) DatasetOption {
+	return func(d *bigquery.Dataset) error {
+		d.Labels = labels
+		return nil
+	}
+}
+
+// DefaultTableExpiration is a dataset option setting the lifetime of new tables created in the dataset,
+// it must be at least one hour
+func DefaultTableExpiration(expiration time.Duration) DatasetOption {
+	return func(d *bigquery.Dataset) error {
+		if expiration < time.Hour {
+			return fmt.Errorf("default table expiration must be at least one hour, got %s", expiration)
+		}
+		d.DefaultTableExpirationMs = int64(expiration / time.Millisecond)
+		return nil
+	}
+}
+
+// DefaultPartitionExpiration is a dataset option setting the lifetime of the partitions of new partitioned tables
+// created in the dataset
+func DefaultPartitionExpiration(expiration time.Duration) DatasetOption {
+	return func(d *bigquery.Dataset) error {
+		d.DefaultPartitionExpirationMs = int64(expiration / time.Millisecond)
+		return nil
+	}
+}
+
+// CreateDataset creates a new dataset in the given project, options can be used to set its location, default
+// expirations, labels and description
+func (c *Client) CreateDataset(ctx context.Context, projectID, datasetID string, options ...DatasetOption) (*DatasetMetadata, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &bigquery.Dataset{
+		DatasetReference: &bigquery.DatasetReference{ProjectId: projectID, DatasetId: datasetID},
+	}
+
+	for _, option := range options {
+		err = option(dataset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ac := c.startCall(ctx, "datasets.insert", "project", projectID, "dataset", datasetID)
+	dataset, err = service.Datasets.Insert(projectID, dataset).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return datasetMetadataFromBigQuery(dataset), nil
+}
+
+// GetDataset loads the metadata of the given dataset
+func (c *Client) GetDataset(ctx context.Context, projectID, datasetID string) (*DatasetMetadata, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ac := c.startCall(ctx, "datasets.get", "project", projectID, "dataset", datasetID)
+	dataset, err := service.Datasets.Get(projectID, datasetID).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return datasetMetadataFromBigQuery(dataset), nil
+}
+
+// DatasetUpdate lists the metadata changes made by UpdateDataset, nil or empty fields are left untouched
+type DatasetUpdate struct {
+	Description  *string
+	FriendlyName *string
+	Labels       map[string]string // labels to add or overwrite
+	DeleteLabels []string          // label keys to remove
+
+	DefaultTableExpiration     *time.Duration // zero removes the default
+	DefaultPartitionExpiration *time.Duration // zero removes the default
+}
+
+// UpdateDataset applies the metadata changes in update to the dataset and returns the updated metadata. If etag is not
+// empty, typically DatasetMetadata.Etag from GetDataset, the update only succeeds if the dataset has not been modified
+// since and a *ConflictError is returned otherwise
+func (c *Client) UpdateDataset(ctx context.Context, projectID, datasetID string, update DatasetUpdate, etag string) (*DatasetMetadata, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := &bigquery.Dataset{}
+
+	if update.Description != nil {
+		patch.Description = *update.Description
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+	}
+
+	if update.FriendlyName != nil {
+		patch.FriendlyName = *update.FriendlyName
+		patch.ForceSendFields = append(patch.ForceSendFields, "FriendlyName")
+	}
+
+	if len(update.Labels) > 0 {
+		patch.Labels = update.Labels
+	}
+	for _, k := range update.DeleteLabels {
+		patch.NullFields = append(patch.NullFields, "Labels."+k)
+	}
+	if len(update.DeleteLabels) > 0 && len(update.Labels) == 0 {
+		// null label values are only sent along with a labels map
+		patch.ForceSendFields = append(patch.ForceSendFields, "Labels")
+	}
+
+	if update.DefaultTableExpiration != nil {
+		if *update.DefaultTableExpiration == 0 {
+			patch.NullFields = append(patch.NullFields, "DefaultTableExpirationMs")
+		} else if err = DefaultTableExpiration(*update.DefaultTableExpiration)(patch); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.DefaultPartitionExpiration != nil {
+		if *update.DefaultPartitionExpiration == 0 {
+			patch.NullFields = append(patch.NullFields, "DefaultPartitionExpirationMs")
+		} else {
+			patch.DefaultPartitionExpirationMs = int64(*update.DefaultPartitionExpiration / time.Millisecond)
+		}
+	}
+
+	return c.patchDataset(ctx, service, projectID, datasetID, patch, etag)
+}
+
+// patchDataset sends the patch, guarded by etag when it is not empty
+func (c *Client) patchDataset(ctx context.Context, service *bigquery.Service, projectID, datasetID string, patch *bigquery.Dataset, etag string) (*DatasetMetadata, error) {
+	call := service.Datasets.Patch(projectID, datasetID, patch)
+	if etag != "" {
+		call.Header().Set("If-Match", etag)
+	}
+
+	ac := c.startCall(ctx, "datasets.patch", "project", projectID, "dataset", datasetID)
+	dataset, err := call.Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, conflictError(err, fmt.Sprintf("%s:%s", projectID, datasetID), etag)
+	}
+
+	return datasetMetadataFromBigQuery(dataset), nil
+}
+
+// DeleteDataset deletes the given dataset, the dataset must be empty unless deleteContents is true
+func (c *Client) DeleteDataset(ctx context.Context, projectID, datasetID string, deleteContents bool) error {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	ac := c.startCall(ctx, "datasets.delete", "project", projectID, "dataset", datasetID, "delete_contents", deleteContents)
+	err = service.Datasets.Delete(projectID, datasetID).DeleteContents(deleteContents).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DatasetIterator iterates over the datasets of a project, loading them a page at a time
+type DatasetIterator struct {
+	ctx       context.Context
+	client    *Client
+	projectID string
+	filter    string
+
+	buf       []*DatasetMetadata
+	pageToken string
+	done      bool
+	err       error
+}
+
+// ListDatasets returns an iterator over the datasets of the project having all the given labels, an empty label value
+// matches any value of the key. The metadata returned is the subset provided by the list API, use GetDataset for the rest
+func (c *Client) ListDatasets(ctx context.Context, projectID string, labels map[string]string) *DatasetIterator {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	filters := make([]string, len(keys))
+	for i, k := range keys {
+		filters[i] = "labels." + k
+		if labels[k] != "" {
+			filters[i] += ":" + labels[k]
+		}
+	}
+
+	return &DatasetIterator{
+		ctx:       ctx,
+		client:    c,
+		projectID: projectID,
+		filter:    strings.Join(filters, " "),
+	}
+}
+
+// Next returns the next dataset, or iterator.Done once every dataset has been returned
+func (it *DatasetIterator) Next() (*DatasetMetadata, error) {
+	for len(it.buf) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if it.done {
+			return nil, iterator.Done
+		}
+		it.err = it.fetch()
+	}
+
+	md := it.buf[0]
+	it.buf = it.buf[1:]
+	return md, nil
+}
+
+// fetch loads the next page of datasets
+func (it *DatasetIterator) fetch() error {
+	service, err := it.client.connect(it.ctx)
+	if err != nil {
+		return err
+	}
+
+	call := service.Datasets.List(it.projectID)
+	if len(it.filter) > 0 {
+		call.Filter(it.filter)
+	}
+	if len(it.pageToken) > 0 {
+		call.PageToken(it.pageToken)
+	}
+
+	ac := it.client.startCall(it.ctx, "datasets.list", "project", it.projectID)
+	list, err := call.Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		return err
+	}
+	ac.end(nil, "datasets", len(list.Datasets))
+
+	for _, d := range list.Datasets {
+		it.buf = append(it.buf, datasetMetadataFromBigQuery(&bigquery.Dataset{
+			DatasetReference: d.DatasetReference,
+			FriendlyName:     d.FriendlyName,
+			Labels:           d.Labels,
+			Location:         d.Location,
+		}))
+	}
+
+	it.pageToken = list.NextPageToken
+	it.done = it.pageToken == ""
+	return nil
+}
+
+func datasetMetadataFromBigQuery(d *bigquery.Dataset) *DatasetMetadata {
+	md := &DatasetMetadata{
+		FriendlyName:               d.FriendlyName,
+		Description:                d.Description,
+		Location:                   d.Location,
+		Labels:                     d.Labels,
+		Etag:                       d.Etag,
+		DefaultTableExpiration:     time.Duration(d.DefaultTableExpirationMs) * time.Millisecond,
+		DefaultPartitionExpiration: time.Duration(d.DefaultPartitionExpirationMs) * time.Millisecond,
+		CreationTime:               msToTime(d.CreationTime),
+		LastModifiedTime:           msToTime(d.LastModifiedTime),
+	}
+
+	for _, a := range d.Access {
+		if e, ok := accessEntryFromBigQuery(a); ok {
+			md.Access = append(md.Access, e)
+		}
+	}
+
+	if d.DatasetReference != nil {
+		md.ProjectID = d.DatasetReference.ProjectId
+		md.DatasetID = d.DatasetReference.DatasetId
+	}
+
+	return md
+}
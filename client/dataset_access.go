@@ -0,0 +1,229 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Dataset roles granted to users, groups, domains and special groups
+const (
+	RoleOwner  = "OWNER"
+	RoleWriter = "WRITER"
+	RoleReader = "READER"
+)
+
+// AccessEntityType identifies who or what an AccessEntry grants access to
+type AccessEntityType string
+
+// The entity types supported in dataset access control lists
+const (
+	UserEntity         AccessEntityType = "user"
+	GroupEntity        AccessEntityType = "group"
+	DomainEntity       AccessEntityType = "domain"
+	SpecialGroupEntity AccessEntityType = "specialGroup"
+	ViewEntity         AccessEntityType = "view"
+	DatasetEntity      AccessEntityType = "dataset"
+	RoutineEntity      AccessEntityType = "routine"
+)
+
+// AccessEntry is a single entry of a dataset access control list, build them with UserAccess, GroupAccess,
+// DomainAccess, SpecialGroupAccess, AuthorizedViewAccess, AuthorizedDatasetAccess or AuthorizedRoutineAccess
+type AccessEntry struct {
+	EntityType AccessEntityType
+	Role       string // empty for authorized views, datasets and routines
+
+	// Entity is the email, domain or special group name for the matching entity types
+	Entity string
+
+	// ProjectID, DatasetID and ResourceID identify authorized views, datasets and routines,
+	// ResourceID is empty for datasets
+	ProjectID   string
+	DatasetID   string
+	ResourceID  string
+	TargetTypes []string // authorized datasets only, defaults to VIEWS
+}
+
+// UserAccess grants role to the user with the given email
+func UserAccess(role, email string) AccessEntry {
+	return AccessEntry{EntityType: UserEntity, Role: role, Entity: email}
+}
+
+// GroupAccess grants role to the google group with the given email
+func GroupAccess(role, email string) AccessEntry {
+	return AccessEntry{EntityType: GroupEntity, Role: role, Entity: email}
+}
+
+// DomainAccess grants role to every user of the given domain
+func DomainAccess(role, domain string) AccessEntry {
+	return AccessEntry{EntityType: DomainEntity, Role: role, Entity: domain}
+}
+
+// SpecialGroupAccess grants role to a special group: projectOwners, projectReaders, projectWriters or
+// allAuthenticatedUsers
+func SpecialGroupAccess(role, group string) AccessEntry {
+	return AccessEntry{EntityType: SpecialGroupEntity, Role: role, Entity: group}
+}
+
+// AuthorizedViewAccess allows the given view to query the dataset regardless of the access of its users
+func AuthorizedViewAccess(projectID, datasetID, viewID string) AccessEntry {
+	return AccessEntry{EntityType: ViewEntity, ProjectID: projectID, DatasetID: datasetID, ResourceID: viewID}
+}
+
+// AuthorizedDatasetAccess allows the resources of the given dataset, its views by default, to query the dataset
+func AuthorizedDatasetAccess(projectID, datasetID string, targetTypes ...string) AccessEntry {
+	if len(targetTypes) == 0 {
+		targetTypes = []string{"VIEWS"}
+	}
+	return AccessEntry{EntityType: DatasetEntity, ProjectID: projectID, DatasetID: datasetID, TargetTypes: targetTypes}
+}
+
+// AuthorizedRoutineAccess allows the given routine to query the dataset
+func AuthorizedRoutineAccess(projectID, datasetID, routineID string) AccessEntry {
+	return AccessEntry{EntityType: RoutineEntity, ProjectID: projectID, DatasetID: datasetID, ResourceID: routineID}
+}
+
+func (e AccessEntry) String() string {
+	switch e.EntityType {
+	case ViewEntity, RoutineEntity:
+		return fmt.Sprintf("%s:%s:%s.%s", e.EntityType, e.ProjectID, e.DatasetID, e.ResourceID)
+	case DatasetEntity:
+		return fmt.Sprintf("%s:%s:%s", e.EntityType, e.ProjectID, e.DatasetID)
+	default:
+		return fmt.Sprintf("%s:%s %s", e.EntityType, e.Entity, e.Role)
+	}
+}
+
+// matches reports whether both entries grant the same access, target types are not compared
+func (e AccessEntry) matches(o AccessEntry) bool {
+	return e.EntityType == o.EntityType && e.Role == o.Role && e.Entity == o.Entity &&
+		e.ProjectID == o.ProjectID && e.DatasetID == o.DatasetID && e.ResourceID == o.ResourceID
+}
+
+func (e AccessEntry) toBigQuery() *bigquery.DatasetAccess {
+	a := &bigquery.DatasetAccess{Role: e.Role}
+	switch e.EntityType {
+	case UserEntity:
+		a.UserByEmail = e.Entity
+	case GroupEntity:
+		a.GroupByEmail = e.Entity
+	case DomainEntity:
+		a.Domain = e.Entity
+	case SpecialGroupEntity:
+		a.SpecialGroup = e.Entity
+	case ViewEntity:
+		a.View = &bigquery.TableReference{ProjectId: e.ProjectID, DatasetId: e.DatasetID, TableId: e.ResourceID}
+	case DatasetEntity:
+		a.Dataset = &bigquery.DatasetAccessEntry{
+			Dataset:     &bigquery.DatasetReference{ProjectId: e.ProjectID, DatasetId: e.DatasetID},
+			TargetTypes: e.TargetTypes,
+		}
+	case RoutineEntity:
+		a.Routine = &bigquery.RoutineReference{ProjectId: e.ProjectID, DatasetId: e.DatasetID, RoutineId: e.ResourceID}
+	}
+	return a
+}
+
+// accessEntryFromBigQuery converts an access entry, ok is false for entity types this package does not model
+func accessEntryFromBigQuery(a *bigquery.DatasetAccess) (e AccessEntry, ok bool) {
+	e.Role = a.Role
+	switch {
+	case a.UserByEmail != "":
+		e.EntityType, e.Entity = UserEntity, a.UserByEmail
+	case a.GroupByEmail != "":
+		e.EntityType, e.Entity = GroupEntity, a.GroupByEmail
+	case a.Domain != "":
+		e.EntityType, e.Entity = DomainEntity, a.Domain
+	case a.SpecialGroup != "":
+		e.EntityType, e.Entity = SpecialGroupEntity, a.SpecialGroup
+	case a.View != nil:
+		e.EntityType, e.ProjectID, e.DatasetID, e.ResourceID = ViewEntity, a.View.ProjectId, a.View.DatasetId, a.View.TableId
+	case a.Dataset != nil && a.Dataset.Dataset != nil:
+		e.EntityType, e.ProjectID, e.DatasetID = DatasetEntity, a.Dataset.Dataset.ProjectId, a.Dataset.Dataset.DatasetId
+		e.TargetTypes = a.Dataset.TargetTypes
+	case a.Routine != nil:
+		e.EntityType, e.ProjectID, e.DatasetID, e.ResourceID = RoutineEntity, a.Routine.ProjectId, a.Routine.DatasetId, a.Routine.RoutineId
+	default:
+		return e, false
+	}
+	return e, true
+}
+
+// GrantDatasetAccess adds the entries to the access control list of the dataset, entries that are already present
+// are left as is. The dataset is updated with a read-modify-write guarded by its ETag and retried when a concurrent
+// change is detected, so concurrent grants do not overwrite each other
+func (c *Client) GrantDatasetAccess(ctx context.Context, projectID, datasetID string, entries ...AccessEntry) (*DatasetMetadata, error) {
+	return c.modifyDatasetAccess(ctx, projectID, datasetID, func(access []*bigquery.DatasetAccess) ([]*bigquery.DatasetAccess, bool) {
+		changed := false
+		for _, entry := range entries {
+			if indexAccessEntry(access, entry) < 0 {
+				c.log().Info("granting dataset access", "project", projectID, "dataset", datasetID, "entry", entry.String())
+				access = append(access, entry.toBigQuery())
+				changed = true
+			}
+		}
+		return access, changed
+	})
+}
+
+// RevokeDatasetAccess removes the entries from the access control list of the dataset, entries that are not present
+// are ignored. Like GrantDatasetAccess the update is guarded by the dataset ETag
+func (c *Client) RevokeDatasetAccess(ctx context.Context, projectID, datasetID string, entries ...AccessEntry) (*DatasetMetadata, error) {
+	return c.modifyDatasetAccess(ctx, projectID, datasetID, func(access []*bigquery.DatasetAccess) ([]*bigquery.DatasetAccess, bool) {
+		changed := false
+		for _, entry := range entries {
+			if i := indexAccessEntry(access, entry); i >= 0 {
+				c.log().Info("revoking dataset access", "project", projectID, "dataset", datasetID, "entry", entry.String())
+				access = append(access[:i], access[i+1:]...)
+				changed = true
+			}
+		}
+		return access, changed
+	})
+}
+
+// modifyDatasetAccess loads the dataset, applies modify to its access list and patches it with the loaded ETag,
+// reloading and retrying up to maxRequestRetry times on conflicts. The dataset is not patched when modify reports no
+// change
+func (c *Client) modifyDatasetAccess(ctx context.Context, projectID, datasetID string, modify func([]*bigquery.DatasetAccess) ([]*bigquery.DatasetAccess, bool)) (*DatasetMetadata, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; ; i++ {
+		ac := c.startCall(ctx, "datasets.get", "project", projectID, "dataset", datasetID)
+		dataset, err := service.Datasets.Get(projectID, datasetID).Context(ac.ctx).Do()
+		ac.end(err)
+		if err != nil {
+			return nil, err
+		}
+
+		access, changed := modify(dataset.Access)
+		if !changed {
+			return datasetMetadataFromBigQuery(dataset), nil
+		}
+
+		// force the access list to be sent even when the last entry was revoked
+		patch := &bigquery.Dataset{Access: access, ForceSendFields: []string{"Access"}}
+		md, err := c.patchDataset(ctx, service, projectID, datasetID, patch, dataset.Etag)
+		var conflict *ConflictError
+		if errors.As(err, &conflict) && i < maxRequestRetry {
+			c.log().Warn("dataset access modified concurrently, retrying", "project", projectID, "dataset", datasetID, "attempt", i)
+			c.metrics().Retried("datasets.patch", errorReason(err))
+			continue
+		}
+		return md, err
+	}
+}
+
+func indexAccessEntry(access []*bigquery.DatasetAccess, entry AccessEntry) int {
+	for i, a := range access {
+		if e, ok := accessEntryFromBigQuery(a); ok && e.matches(entry) {
+			return i
+		}
+	}
+	return -1
+}
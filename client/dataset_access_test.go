@@ -0,0 +1,124 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// patchedAccess returns the access lists sent by the datasets.patch calls
+func patchedAccess(t *testing.T, calls *callCounter) [][]*bigquery.DatasetAccess {
+	t.Helper()
+
+	var patched [][]*bigquery.DatasetAccess
+	for _, body := range calls.bodies["datasets.patch"] {
+		var patch struct {
+			Access []*bigquery.DatasetAccess `json:"access"`
+		}
+		if err := json.Unmarshal([]byte(body), &patch); err != nil {
+			t.Fatal(err)
+		}
+		patched = append(patched, patch.Access)
+	}
+	return patched
+}
+
+func TestGrantDatasetAccess(t *testing.T) {
+	bq, metrics, calls := meteredClient(t, "testdata/dataset_access_grant.json")
+
+	md, err := bq.GrantDatasetAccess(context.Background(), "proj", "ds",
+		client.UserAccess(client.RoleReader, "a@example.com"),
+		client.GroupAccess(client.RoleWriter, "g@example.com"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Etag != "e3" || len(md.Access) != 4 {
+		t.Errorf("unexpected dataset %+v", md)
+	}
+
+	// the first patch conflicts, the retry keeps the entry granted concurrently
+	patched := patchedAccess(t, calls)
+	if len(patched) != 2 {
+		t.Fatalf("%d patches, want 2", len(patched))
+	}
+	want := []*bigquery.DatasetAccess{
+		{Role: "READER", UserByEmail: "a@example.com"},
+		{Role: "OWNER", SpecialGroup: "projectOwners"},
+		{Role: "WRITER", UserByEmail: "b@example.com"},
+		{Role: "WRITER", GroupByEmail: "g@example.com"},
+	}
+	if !reflect.DeepEqual(patched[1], want) {
+		t.Errorf("patched access = %+v, want %+v", patched[1], want)
+	}
+
+	headers := calls.headers["datasets.patch"]
+	if headers[0].Get("If-Match") != "e1" || headers[1].Get("If-Match") != "e2" {
+		t.Errorf("patch etags = %q and %q, want e1 and e2", headers[0].Get("If-Match"), headers[1].Get("If-Match"))
+	}
+	if n := metrics.Retries("datasets.patch", "conflict"); n != 1 {
+		t.Errorf("%d retries recorded, want 1", n)
+	}
+}
+
+func TestRevokeDatasetAccess(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/dataset_access_revoke.json")
+
+	_, err := bq.RevokeDatasetAccess(context.Background(), "proj", "ds", client.UserAccess(client.RoleReader, "a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the emptied access list is still sent
+	if len(calls.bodies["datasets.patch"]) != 1 || calls.bodies["datasets.patch"][0] != `{"access":[]}`+"\n" {
+		t.Errorf("patches = %q, want an empty access list", calls.bodies["datasets.patch"])
+	}
+}
+
+func TestDatasetAccessUnchanged(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/dataset_access_unchanged.json")
+
+	md, err := bq.GrantDatasetAccess(context.Background(), "proj", "ds", client.UserAccess(client.RoleReader, "a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Access) != 1 {
+		t.Errorf("unexpected dataset %+v", md)
+	}
+
+	_, err = bq.RevokeDatasetAccess(context.Background(), "proj", "ds", client.UserAccess(client.RoleWriter, "a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := calls.calls["datasets.patch"]; n != 0 {
+		t.Errorf("%d patches, want none for unchanged access lists", n)
+	}
+}
+
+func TestDatasetAccessEntries(t *testing.T) {
+	bq := replayClient(t, "testdata/dataset_access_entries.json")
+
+	md, err := bq.GetDataset(context.Background(), "proj", "ds")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the IAM member entry is not modelled and left out
+	want := []client.AccessEntry{
+		client.UserAccess(client.RoleReader, "a@example.com"),
+		client.GroupAccess(client.RoleWriter, "g@example.com"),
+		client.DomainAccess(client.RoleReader, "example.com"),
+		client.SpecialGroupAccess(client.RoleOwner, "projectOwners"),
+		client.AuthorizedViewAccess("proj", "views", "v"),
+		client.AuthorizedDatasetAccess("proj", "shared"),
+		client.AuthorizedRoutineAccess("proj", "fns", "r"),
+	}
+	if !reflect.DeepEqual(md.Access, want) {
+		t.Errorf("access = %+v, want %+v", md.Access, want)
+	}
+}
@@ -0,0 +1,52 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"google.golang.org/api/iterator"
+)
+
+func TestListDatasets(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/datasets_list.json")
+
+	it := bq.ListDatasets(context.Background(), "proj", map[string]string{"team": "", "env": "prod"})
+	md, err := it.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.DatasetID != "analytics" || md.Location != "EU" || md.Labels["team"] != "data" {
+		t.Errorf("unexpected dataset %+v", md)
+	}
+	if _, err = it.Next(); err != iterator.Done {
+		t.Errorf("err = %v, want iterator.Done", err)
+	}
+
+	if len(calls.urls) != 1 {
+		t.Fatalf("%d datasets.list calls, want 1", len(calls.urls))
+	}
+	// the labels are sorted and an empty value matches any value
+	if filter := calls.urls[0].Query().Get("filter"); filter != "labels.env:prod labels.team" {
+		t.Errorf("filter = %q, want %q", filter, "labels.env:prod labels.team")
+	}
+}
+
+func TestUpdateDatasetDeleteLabels(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/dataset_update.json")
+
+	md, err := bq.UpdateDataset(context.Background(), "proj", "ds", client.DatasetUpdate{DeleteLabels: []string{"tmp"}}, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Etag != "e2" {
+		t.Errorf("etag = %s, want e2", md.Etag)
+	}
+
+	if body, want := calls.bodies["datasets.patch"][0], `{"labels":{"tmp":null}}`+"\n"; body != want {
+		t.Errorf("patch = %s, want %s", body, want)
+	}
+	if etag := calls.headers["datasets.patch"][0].Get("If-Match"); etag != "e1" {
+		t.Errorf("If-Match = %q, want e1", etag)
+	}
+}
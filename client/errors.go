@@ -0,0 +1,52 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// ConflictError is returned when an update was made with an ETag that no longer matches the resource, meaning
+// someone else modified it since it was read. Reload the resource and retry the update
+type ConflictError struct {
+	Resource string
+	Etag     string
+	Err      error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s was modified concurrently, etag %s is stale: %v", e.Resource, e.Etag, e.Err)
+}
+
+// Unwrap returns the underlying API error
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// JobWaitError is returned when a job is still running after the max wait set with MaxJobWait. The job is not
+// cancelled, use CancelJob to stop it
+type JobWaitError struct {
+	JobID  string
+	Waited time.Duration
+}
+
+func (e *JobWaitError) Error() string {
+	return fmt.Sprintf("job %s not complete after %s", e.JobID, e.Waited.Round(time.Millisecond))
+}
+
+// isHTTPStatus reports whether err is a bigquery API error with the given HTTP status code
+func isHTTPStatus(err error, code int) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// conflictError wraps err in a *ConflictError if the API rejected an ETag precondition, otherwise it returns err
+func conflictError(err error, resource, etag string) error {
+	if etag != "" && isHTTPStatus(err, http.StatusPreconditionFailed) {
+		return &ConflictError{Resource: resource, Etag: etag, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestIsHTTPStatus(t *testing.T) {
+	notFound := &googleapi.Error{Code: http.StatusNotFound}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"api error", notFound, true},
+		{"wrapped api error", fmt.Errorf("deleting table: %w", notFound), true},
+		{"other status", &googleapi.Error{Code: http.StatusForbidden}, false},
+		{"other error", errors.New("not found"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isHTTPStatus(tt.err, http.StatusNotFound); got != tt.want {
+				t.Errorf("isHTTPStatus = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// ExecResult is the outcome of a DML statement run with Exec
+type ExecResult struct {
+	AffectedRows int64 // total rows inserted, updated or deleted
+	InsertedRows int64
+	UpdatedRows  int64
+	DeletedRows  int64
+
+	ProjectID string
+	JobID     string
+	Location  string
+	Stats     *QueryStats
+}
+
+// Exec runs an INSERT, UPDATE, DELETE or MERGE statement in standard SQL and returns the number of affected rows once
+// the job completes. params are bound positionally to ? placeholders, or by name to @name placeholders when passed as
+// QueryParameter values. The client default QueryOptions apply
+func (c *Client) Exec(ctx context.Context, dataset, project, queryStr string, params ...interface{}) (*ExecResult, error) {
+	return c.ExecWithOptions(ctx, dataset, project, queryStr, QueryOptions{Parameters: params})
+}
+
+// ExecWithOptions is Exec with QueryOptions overriding the client defaults, the parameters are taken from
+// opts.Parameters. The statement always runs in standard SQL
+func (c *Client) ExecWithOptions(ctx context.Context, dataset, project, queryStr string, opts QueryOptions) (*ExecResult, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// DML and query parameters are only supported by standard SQL
+	opts = c.queryOptions.merge(opts, QueryOptions{UseStandardSQL: Bool(true)})
+	job, err := newQueryJob(queryStr, project, dataset, nil, QueryJobConfig{QueryOptions: opts})
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	ac := c.startCall(ctx, "jobs.insert", "project", project, "dataset", dataset)
+	job, err = service.Jobs.Insert(project, job).Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		c.recordQuery(project, dataset, opts, start, nil, err)
+		return nil, err
+	}
+	ac.end(nil, "job_id", jobID(job.JobReference))
+
+	job, err = c.waitForJob(ctx, service, job.JobReference)
+	stats := queryStatsFromJob(job)
+	c.recordQuery(project, dataset, opts, start, stats, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExecResult{
+		AffectedRows: stats.NumDMLAffectedRows,
+		InsertedRows: stats.InsertedRows,
+		UpdatedRows:  stats.UpdatedRows,
+		DeletedRows:  stats.DeletedRows,
+		ProjectID:    stats.ProjectID,
+		JobID:        stats.JobID,
+		Location:     stats.Location,
+		Stats:        stats,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func TestExecWithOptions(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/exec.json")
+
+	res, err := bq.ExecWithOptions(context.Background(), "ds", "proj", "UPDATE t SET a = @a WHERE b = @b", client.QueryOptions{
+		Priority:      client.PriorityBatch,
+		Labels:        map[string]string{"team": "data"},
+		Location:      "EU",
+		UseQueryCache: client.Bool(false),
+		Parameters:    []interface{}{client.QueryParameter{Name: "a", Value: "x"}, client.QueryParameter{Name: "b", Value: int64(1)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AffectedRows != 3 || res.UpdatedRows != 3 || res.JobID != "job_1" || res.Location != "EU" {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	var job bigquery.Job
+	if err = json.Unmarshal([]byte(calls.bodies["jobs.insert"][0]), &job); err != nil {
+		t.Fatal(err)
+	}
+	q := job.Configuration.Query
+	if q.Priority != client.PriorityBatch || q.UseLegacySql == nil || *q.UseLegacySql || q.UseQueryCache == nil || *q.UseQueryCache {
+		t.Errorf("unexpected query job %+v", q)
+	}
+	if !reflect.DeepEqual(job.Configuration.Labels, map[string]string{"team": "data"}) || job.JobReference.Location != "EU" {
+		t.Errorf("unexpected job %+v", job)
+	}
+	if q.ParameterMode != "NAMED" || len(q.QueryParameters) != 2 || q.QueryParameters[1].ParameterValue.Value != "1" {
+		t.Errorf("unexpected parameters %s %+v", q.ParameterMode, q.QueryParameters)
+	}
+}
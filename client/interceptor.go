@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+// Invoker sends an API request and returns its response, it is the rest of the interceptor chain
+type Invoker func(req *http.Request) (*http.Response, error)
+
+// Interceptor is called around every outgoing API request. method names the API method, e.g. jobs.insert or
+// tables.get. An interceptor can inspect or modify req, including its headers, before calling next to send it, and
+// inspect or replace the response and error next returns. It can also return without calling next, e.g. to inject
+// faults or enforce a quota.
+//
+// req is a copy made for the chain so its headers can be changed in place. Read the body through req.GetBody, when
+// set, to leave req.Body for the API. The request is not yet authorized, no credentials are visible to interceptors
+type Interceptor func(method string, req *http.Request, next Invoker) (*http.Response, error)
+
+// WithInterceptors is a configuration function that adds interceptors around the API requests of the client. The
+// first interceptor registered is the outermost, it sees the request first and the response last
+//
+// An example use is:
+//
+//	client.New(pemPath, client.WithInterceptors(func(method string, req *http.Request, next client.Invoker) (*http.Response, error) {
+//		req.Header.Set("User-Agent", "reporting-service/1.0")
+//		return next(req)
+//	}))
+func WithInterceptors(interceptors ...Interceptor) func(*Client) error {
+	return func(c *Client) error {
+		c.interceptors = append(c.interceptors, interceptors...)
+		return nil
+	}
+}
+
+// callNameKey is the context key of the API method name set by startCall
+type callNameKey struct{}
+
+// withCallName returns a context carrying the API method name for the interceptors
+func withCallName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, callNameKey{}, name)
+}
+
+// callName returns the API method name of the request, falling back to the HTTP method and path for requests not made
+// through startCall
+func callName(req *http.Request) string {
+	if name, ok := req.Context().Value(callNameKey{}).(string); ok {
+		return name
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+// requestSizeKey is the context key of the counter of request body bytes set by withRequestSize
+type requestSizeKey struct{}
+
+// withRequestSize returns a context counting the size of the bodies of the requests sent with it, when the client
+// records metrics
+func withRequestSize(ctx context.Context) (context.Context, *int64) {
+	size := new(int64)
+	return context.WithValue(ctx, requestSizeKey{}, size), size
+}
+
+// interceptTransport runs the interceptors of the client around the base transport and measures the request bodies
+// for withRequestSize
+type interceptTransport struct {
+	interceptors []Interceptor
+	base         http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *interceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := callName(req)
+	if size, ok := req.Context().Value(requestSizeKey{}).(*int64); ok && req.ContentLength > 0 {
+		atomic.AddInt64(size, req.ContentLength)
+	}
+
+	next := t.base.RoundTrip
+	for i := len(t.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := t.interceptors[i], next
+		next = func(req *http.Request) (*http.Response, error) {
+			return interceptor(method, req, inner)
+		}
+	}
+
+	// round trippers must not modify the request they are given
+	return next(req.Clone(req.Context()))
+}
+
+// withInterceptors wraps the transport of client with the interceptors of the client, if any, or to measure requests
+// when recording metrics
+func (c *Client) withInterceptors(client *http.Client) *http.Client {
+	if len(c.interceptors) == 0 && c.metricsRecorder == nil {
+		return client
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &interceptTransport{interceptors: c.interceptors, base: base}
+	return &wrapped
+}
@@ -0,0 +1,85 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	rec, err := clienttest.NewRecorder("testdata/query_single_page.json", clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+
+	var mu sync.Mutex
+	var events []string
+	trace := func(name string) client.Interceptor {
+		return func(method string, req *http.Request, next client.Invoker) (*http.Response, error) {
+			mu.Lock()
+			events = append(events, "in "+name+" "+method)
+			mu.Unlock()
+
+			req.Header.Set("X-Interceptor", name)
+			resp, err := next(req)
+
+			mu.Lock()
+			events = append(events, "out "+name)
+			mu.Unlock()
+			return resp, err
+		}
+	}
+
+	calls := &callCounter{}
+	bq := client.New("", client.WithHTTPClient(rec.Client()),
+		client.WithInterceptors(trace("a"), trace("b")), client.WithInterceptors(calls.intercept))
+	if _, _, err = bq.Query("", "proj", "SELECT a FROM t"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"in a jobs.query", "in b jobs.query", "out b", "out a"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	// the innermost interceptor sees the header set last
+	if h := calls.headers["jobs.query"][0].Get("X-Interceptor"); h != "b" {
+		t.Errorf("X-Interceptor = %q, want b", h)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	// the golden file only has a query, a tables.get request reaching the transport fails
+	rec, err := clienttest.NewRecorder("testdata/query_single_page.json", clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := func(method string, req *http.Request, next client.Invoker) (*http.Response, error) {
+		if method != "tables.get" {
+			return next(req)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"etag": "e1", "tableReference": {"projectId": "proj", "datasetId": "ds", "tableId": "events"}}`)),
+			Request:    req,
+		}, nil
+	}
+
+	bq := client.New("", client.WithHTTPClient(rec.Client()), client.WithInterceptors(table))
+	md, err := bq.GetTable(context.Background(), "proj", "ds", "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Etag != "e1" {
+		t.Errorf("etag = %s, want e1", md.Etag)
+	}
+}
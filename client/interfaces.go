@@ -0,0 +1,52 @@
+package client
+
+import "context"
+
+// Querier runs queries, it is implemented by *Client and by clienttest.Mock so that code running queries can be tested
+// without bigquery
+type Querier interface {
+	Query(dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, error)
+	QueryContext(ctx context.Context, dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, error)
+	AsyncQuery(pageSize int, dataset, project, queryStr string, dataChan chan Data, opts ...QueryOptions)
+	AsyncQueryContext(ctx context.Context, pageSize int, dataset, project, queryStr string, dataChan chan Data, opts ...QueryOptions)
+	Rows(ctx context.Context, pageSize int, dataset, project, queryStr string, opts ...QueryOptions) *RowIterator
+	SyncQuery(dataset, project, queryStr string, maxResults int64, opts ...QueryOptions) ([][]interface{}, error)
+	SyncQueryContext(ctx context.Context, dataset, project, queryStr string, maxResults int64, opts ...QueryOptions) ([][]interface{}, error)
+	QueryWithStats(dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, *QueryStats, error)
+	QueryWithStatsContext(ctx context.Context, dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, *QueryStats, error)
+	Exec(ctx context.Context, dataset, project, queryStr string, params ...interface{}) (*ExecResult, error)
+	ExecWithOptions(ctx context.Context, dataset, project, queryStr string, opts QueryOptions) (*ExecResult, error)
+	QueryToTable(ctx context.Context, queryStr, projectID, datasetID, tableID string, config QueryJobConfig) (*QueryStats, error)
+	DryRun(ctx context.Context, dataset, project, queryStr string, opts ...QueryOptions) (*QueryStats, error)
+	Count(dataset, project, datasetTable string) int64
+}
+
+// Inserter writes rows to tables, it is implemented by *Client and by clienttest.Mock
+type Inserter interface {
+	InsertRow(projectID, datasetID, tableID string, rowData map[string]interface{}) error
+	InsertRows(projectID, datasetID, tableID string, rows []map[string]interface{}) error
+	InsertRowsContext(ctx context.Context, projectID, datasetID, tableID string, rows []map[string]interface{}) error
+	Upsert(ctx context.Context, projectID, datasetID, tableID string, keyColumns []string, rows []map[string]interface{}) (*ExecResult, error)
+}
+
+// TableAdmin creates, inspects and changes tables and views, it is implemented by *Client and by clienttest.Mock.
+// ListTables is not part of it as its iterator can only be created by a Client
+type TableAdmin interface {
+	InsertNewTable(projectID, datasetID, tableName string, fields map[string]string, options ...TableOption) error
+	InsertNewTableIfDoesNotExist(projectID, datasetID, tableID string, fields map[string]string, options ...TableOption) error
+	PatchTableSchema(projectID, datasetID, tableID string, fields map[string]string) error
+	EvolveSchema(ctx context.Context, projectID, datasetID, tableID string, desired Schema, policy SchemaPolicy) ([]SchemaChange, error)
+	CheckTable(ctx context.Context, projectID, datasetID, tableID string, desired Schema) error
+	GetTable(ctx context.Context, projectID, datasetID, tableID string) (*TableMetadata, error)
+	UpdateTable(ctx context.Context, projectID, datasetID, tableID string, update TableUpdate, etag string) (*TableMetadata, error)
+	DeleteTable(ctx context.Context, projectID, datasetID, tableID string, ignoreNotFound bool) error
+	CreateView(ctx context.Context, projectID, datasetID, viewID, query string, opts ViewOptions) error
+	CreateMaterializedView(ctx context.Context, projectID, datasetID, viewID, query string, opts MaterializedViewOptions, options ...TableOption) error
+	UpdateViewQuery(ctx context.Context, projectID, datasetID, viewID, query string) error
+}
+
+var (
+	_ Querier    = (*Client)(nil)
+	_ Inserter   = (*Client)(nil)
+	_ TableAdmin = (*Client)(nil)
+)
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/iterator"
+)
+
+// Job states
+const (
+	JobPending = "PENDING"
+	JobRunning = "RUNNING"
+	JobDone    = "DONE"
+)
+
+// JobInfo describes a job of a project
+type JobInfo struct {
+	ProjectID string
+	JobID     string
+	Location  string
+
+	Type         string // QUERY, LOAD, EXTRACT or COPY
+	State        string // one of the Job state constants
+	UserEmail    string
+	ErrorMessage string // set when the job failed
+	Labels       map[string]string
+
+	CreationTime time.Time
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+func jobInfoFromBigQuery(ref *bigquery.JobReference, config *bigquery.JobConfiguration, status *bigquery.JobStatus,
+	stats *bigquery.JobStatistics, userEmail string) *JobInfo {
+	info := &JobInfo{UserEmail: userEmail}
+	if ref != nil {
+		info.ProjectID = ref.ProjectId
+		info.JobID = ref.JobId
+		info.Location = ref.Location
+	}
+	if config != nil {
+		info.Type = config.JobType
+		info.Labels = config.Labels
+	}
+	if status != nil {
+		info.State = status.State
+		if status.ErrorResult != nil {
+			info.ErrorMessage = status.ErrorResult.Message
+		}
+	}
+	if stats != nil {
+		info.CreationTime = msToTime(stats.CreationTime)
+		info.StartTime = msToTime(stats.StartTime)
+		info.EndTime = msToTime(stats.EndTime)
+	}
+	return info
+}
+
+// JobFilter restricts the jobs returned by ListJobs, empty fields match every job
+type JobFilter struct {
+	AllUsers        bool     // list the jobs of every user, which requires the bigquery.jobs.listAll permission
+	States          []string // Job state constants
+	MinCreationTime time.Time
+	MaxCreationTime time.Time
+}
+
+// JobIterator iterates over the jobs of a project, most recent first, loading them a page at a time
+type JobIterator struct {
+	ctx       context.Context
+	client    *Client
+	projectID string
+	filter    JobFilter
+
+	buf       []*JobInfo
+	pageToken string
+	done      bool
+	err       error
+}
+
+// ListJobs returns an iterator over the jobs of the project matching filter
+func (c *Client) ListJobs(ctx context.Context, projectID string, filter JobFilter) *JobIterator {
+	return &JobIterator{
+		ctx:       ctx,
+		client:    c,
+		projectID: projectID,
+		filter:    filter,
+	}
+}
+
+// Next returns the next job, or iterator.Done once every job has been returned
+func (it *JobIterator) Next() (*JobInfo, error) {
+	for len(it.buf) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if it.done {
+			return nil, iterator.Done
+		}
+		it.err = it.fetch()
+	}
+
+	info := it.buf[0]
+	it.buf = it.buf[1:]
+	return info, nil
+}
+
+// fetch loads the next page of jobs
+func (it *JobIterator) fetch() error {
+	service, err := it.client.connect(it.ctx)
+	if err != nil {
+		return err
+	}
+
+	call := service.Jobs.List(it.projectID).AllUsers(it.filter.AllUsers).Projection("full")
+	if len(it.filter.States) > 0 {
+		states := make([]string, len(it.filter.States))
+		for i, s := range it.filter.States {
+			states[i] = strings.ToLower(s)
+		}
+		call.StateFilter(states...)
+	}
+	if !it.filter.MinCreationTime.IsZero() {
+		call.MinCreationTime(uint64(it.filter.MinCreationTime.UnixNano() / int64(time.Millisecond)))
+	}
+	if !it.filter.MaxCreationTime.IsZero() {
+		call.MaxCreationTime(uint64(it.filter.MaxCreationTime.UnixNano() / int64(time.Millisecond)))
+	}
+	if len(it.pageToken) > 0 {
+		call.PageToken(it.pageToken)
+	}
+
+	ac := it.client.startCall(it.ctx, "jobs.list", "project", it.projectID)
+	list, err := call.Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		return err
+	}
+	ac.end(nil, "jobs", len(list.Jobs))
+
+	for _, j := range list.Jobs {
+		info := jobInfoFromBigQuery(j.JobReference, j.Configuration, j.Status, j.Statistics, j.UserEmail)
+		if info.ErrorMessage == "" && j.ErrorResult != nil {
+			info.ErrorMessage = j.ErrorResult.Message
+		}
+		it.buf = append(it.buf, info)
+	}
+
+	it.pageToken = list.NextPageToken
+	it.done = it.pageToken == ""
+	return nil
+}
+
+// GetJob returns the job, location is required for jobs running outside the US and EU multi-regions
+func (c *Client) GetJob(ctx context.Context, projectID, jobID, location string) (*JobInfo, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	call := service.Jobs.Get(projectID, jobID)
+	if len(location) > 0 {
+		call.Location(location)
+	}
+
+	ac := c.startCall(ctx, "jobs.get", "project", projectID, "job_id", jobID)
+	job, err := call.Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return jobInfoFromBigQuery(job.JobReference, job.Configuration, job.Status, job.Statistics, job.UserEmail), nil
+}
+
+// CancelJob requests the cancellation of the job and returns it, cancellation is asynchronous so the job may still be
+// running when CancelJob returns
+func (c *Client) CancelJob(ctx context.Context, projectID, jobID, location string) (*JobInfo, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	call := service.Jobs.Cancel(projectID, jobID)
+	if len(location) > 0 {
+		call.Location(location)
+	}
+
+	ac := c.startCall(ctx, "jobs.cancel", "project", projectID, "job_id", jobID)
+	resp, err := call.Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	job := resp.Job
+	if job == nil {
+		return &JobInfo{ProjectID: projectID, JobID: jobID, Location: location}, nil
+	}
+	return jobInfoFromBigQuery(job.JobReference, job.Configuration, job.Status, job.Statistics, job.UserEmail), nil
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// File formats of load jobs sources and extract jobs destinations
+const (
+	FormatCSV     = "CSV"
+	FormatNDJSON  = "NEWLINE_DELIMITED_JSON"
+	FormatAvro    = "AVRO"
+	FormatParquet = "PARQUET"
+	FormatORC     = "ORC" // load jobs only
+)
+
+// LoadConfig configures a load job, the data is read from SourceURIs or uploaded from Source
+type LoadConfig struct {
+	SourceURIs []string  // gs://bucket/path URIs, wildcards are allowed
+	Source     io.Reader // data uploaded with the job when SourceURIs is empty
+	Format     string    // one of the Format constants, CSV by default
+
+	Schema     Schema // the schema of the data, nil to use the schema of the existing table or Autodetect
+	Autodetect bool   // infer the schema from the data, CSV and NDJSON only
+
+	SkipLeadingRows     int64  // CSV only, e.g. 1 to skip a header row
+	FieldDelimiter      string // CSV only, a comma by default
+	MaxBadRecords       int64  // rows that can be rejected before the job fails
+	IgnoreUnknownValues bool   // ignore values not matching the schema instead of rejecting the row
+
+	WriteDisposition    string // WRITE_APPEND by default, see the Write constants
+	CreateDisposition   string // CREATE_IF_NEEDED by default, see the Create constants
+	SchemaUpdateOptions []string
+
+	// DestinationOptions partition and cluster the destination table when the job creates it
+	DestinationOptions []TableOption
+
+	Labels   map[string]string
+	Location string
+}
+
+// LoadStats are the statistics of a completed load job
+type LoadStats struct {
+	ProjectID string
+	JobID     string
+	Location  string
+
+	InputFiles  int64
+	InputBytes  int64
+	OutputRows  int64
+	OutputBytes int64
+	BadRecords  int64
+}
+
+// Load runs a load job writing the data of config into the table and waits for it to complete
+func (c *Client) Load(ctx context.Context, projectID, datasetID, tableID string, config LoadConfig) (*LoadStats, error) {
+	if len(config.SourceURIs) == 0 && config.Source == nil {
+		return nil, fmt.Errorf("load into %s:%s.%s requires source URIs or a source reader", projectID, datasetID, tableID)
+	}
+
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the destination options to a scratch table to reuse the table creation options
+	destination := &bigquery.Table{}
+	for _, option := range config.DestinationOptions {
+		if err = option(destination); err != nil {
+			return nil, err
+		}
+	}
+
+	load := &bigquery.JobConfigurationLoad{
+		DestinationTable:    &bigquery.TableReference{ProjectId: projectID, DatasetId: datasetID, TableId: tableID},
+		SourceUris:          config.SourceURIs,
+		SourceFormat:        strings.ToUpper(config.Format),
+		Autodetect:          config.Autodetect,
+		SkipLeadingRows:     config.SkipLeadingRows,
+		FieldDelimiter:      config.FieldDelimiter,
+		MaxBadRecords:       config.MaxBadRecords,
+		IgnoreUnknownValues: config.IgnoreUnknownValues,
+		WriteDisposition:    config.WriteDisposition,
+		CreateDisposition:   config.CreateDisposition,
+		SchemaUpdateOptions: config.SchemaUpdateOptions,
+		TimePartitioning:    destination.TimePartitioning,
+		RangePartitioning:   destination.RangePartitioning,
+		Clustering:          destination.Clustering,
+	}
+	if config.Schema != nil {
+		load.Schema = config.Schema.toBigQuery()
+	}
+
+	job := &bigquery.Job{
+		JobReference:  jobLocation(projectID, config.Location),
+		Configuration: &bigquery.JobConfiguration{Load: load, Labels: config.Labels},
+	}
+
+	ac := c.startCall(ctx, "jobs.insert", "project", projectID, "dataset", datasetID, "table", tableID, "uris", len(config.SourceURIs))
+	call := service.Jobs.Insert(projectID, job).Context(ac.ctx)
+	if len(config.SourceURIs) == 0 {
+		call.Media(config.Source)
+	}
+	job, err = call.Do()
+	if err != nil {
+		ac.end(err)
+		return nil, err
+	}
+	ac.end(nil, "job_id", jobID(job.JobReference))
+
+	job, err = c.waitForJob(ctx, service, job.JobReference)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &LoadStats{}
+	if job.JobReference != nil {
+		stats.ProjectID = job.JobReference.ProjectId
+		stats.JobID = job.JobReference.JobId
+		stats.Location = job.JobReference.Location
+	}
+	if job.Statistics != nil && job.Statistics.Load != nil {
+		ls := job.Statistics.Load
+		stats.InputFiles = ls.InputFiles
+		stats.InputBytes = ls.InputFileBytes
+		stats.OutputRows = ls.OutputRows
+		stats.OutputBytes = ls.OutputBytes
+		stats.BadRecords = ls.BadRecords
+	}
+
+	return stats, nil
+}
+
+// ExtractConfig configures an extract job
+type ExtractConfig struct {
+	DestinationURIs []string // gs://bucket/path URIs, a single * wildcard splits large tables in several files
+	Format          string   // CSV, NEWLINE_DELIMITED_JSON, AVRO or PARQUET, CSV by default
+	Compression     string   // GZIP, DEFLATE, SNAPPY or ZSTD depending on the format, none by default
+
+	FieldDelimiter string // CSV only, a comma by default
+	NoHeader       bool   // CSV only, do not write a header row
+
+	Labels   map[string]string
+	Location string
+}
+
+// ExtractStats are the statistics of a completed extract job
+type ExtractStats struct {
+	ProjectID string
+	JobID     string
+	Location  string
+
+	DestinationFileCounts []int64 // the number of files written for each destination URI
+	InputBytes            int64
+}
+
+// Extract runs an extract job exporting the table to Cloud Storage and waits for it to complete
+func (c *Client) Extract(ctx context.Context, projectID, datasetID, tableID string, config ExtractConfig) (*ExtractStats, error) {
+	if len(config.DestinationURIs) == 0 {
+		return nil, fmt.Errorf("extract of %s:%s.%s requires destination URIs", projectID, datasetID, tableID)
+	}
+
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	extract := &bigquery.JobConfigurationExtract{
+		SourceTable:       &bigquery.TableReference{ProjectId: projectID, DatasetId: datasetID, TableId: tableID},
+		DestinationUris:   config.DestinationURIs,
+		DestinationFormat: strings.ToUpper(config.Format),
+		Compression:       strings.ToUpper(config.Compression),
+		FieldDelimiter:    config.FieldDelimiter,
+	}
+	if config.NoHeader {
+		f := false
+		extract.PrintHeader = &f
+	}
+
+	job := &bigquery.Job{
+		JobReference:  jobLocation(projectID, config.Location),
+		Configuration: &bigquery.JobConfiguration{Extract: extract, Labels: config.Labels},
+	}
+
+	ac := c.startCall(ctx, "jobs.insert", "project", projectID, "dataset", datasetID, "table", tableID, "uris", len(config.DestinationURIs))
+	job, err = service.Jobs.Insert(projectID, job).Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		return nil, err
+	}
+	ac.end(nil, "job_id", jobID(job.JobReference))
+
+	job, err = c.waitForJob(ctx, service, job.JobReference)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ExtractStats{}
+	if job.JobReference != nil {
+		stats.ProjectID = job.JobReference.ProjectId
+		stats.JobID = job.JobReference.JobId
+		stats.Location = job.JobReference.Location
+	}
+	if job.Statistics != nil && job.Statistics.Extract != nil {
+		stats.DestinationFileCounts = job.Statistics.Extract.DestinationUriFileCounts
+		stats.InputBytes = job.Statistics.Extract.InputBytes
+	}
+
+	return stats, nil
+}
+
+// jobLocation returns a reference for a job to run in location, nil to let the API choose when location is empty
+func jobLocation(projectID, location string) *bigquery.JobReference {
+	if len(location) == 0 {
+		return nil
+	}
+	return &bigquery.JobReference{ProjectId: projectID, Location: location}
+}
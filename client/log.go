@@ -0,0 +1,61 @@
+package client
+
+import (
+	"log/slog"
+	"os"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Logger receives the structured logs of the client as a message followed by alternating key/value pairs, e.g.
+// "job_id", id, "rows", n. A *slog.Logger implements it as is, adapters for zap, zerolog or other loggers only need to
+// forward the four methods. Filtering by level is left to the logger
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// WithLogger is a configuration function that sets the logger receiving the client logs, it takes precedence over
+// PrintDebug
+//
+// An example use is:
+//
+// client.New(pemPath, client.WithLogger(slog.Default()))
+func WithLogger(l Logger) func(*Client) error {
+	return func(c *Client) error {
+		c.logger = l
+		return nil
+	}
+}
+
+// stdoutLogger is used when PrintDebug is set and no logger was configured
+var stdoutLogger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...interface{}) {}
+func (nopLogger) Info(msg string, args ...interface{})  {}
+func (nopLogger) Warn(msg string, args ...interface{})  {}
+func (nopLogger) Error(msg string, args ...interface{}) {}
+
+// log returns the configured logger, a debug logger writing to stdout if PrintDebug is set or a logger discarding
+// everything otherwise
+func (c *Client) log() Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	if c.PrintDebug {
+		return stdoutLogger
+	}
+	return nopLogger{}
+}
+
+// jobID returns the id of the referenced job for logging, or an empty string if there is none
+func jobID(ref *bigquery.JobReference) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.JobId
+}
@@ -0,0 +1,112 @@
+package client_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+)
+
+// logEntry is a log received by captureLogger
+type logEntry struct {
+	level string
+	msg   string
+	args  map[string]interface{}
+}
+
+// captureLogger is a client.Logger keeping every log
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *captureLogger) log(level, msg string, args []interface{}) {
+	entry := logEntry{level: level, msg: msg, args: map[string]interface{}{}}
+	for i := 0; i+1 < len(args); i += 2 {
+		entry.args[args[i].(string)] = args[i+1]
+	}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func (l *captureLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg, args) }
+func (l *captureLogger) Info(msg string, args ...interface{})  { l.log("info", msg, args) }
+func (l *captureLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg, args) }
+func (l *captureLogger) Error(msg string, args ...interface{}) { l.log("error", msg, args) }
+
+// find returns the first log with the message for the API call
+func (l *captureLogger) find(msg, call string) *logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, e := range l.entries {
+		if e.msg == msg && e.args["call"] == call {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+func loggedClient(t *testing.T, golden string) (*client.Client, *captureLogger) {
+	t.Helper()
+
+	rec, err := clienttest.NewRecorder(golden, clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	logger := &captureLogger{}
+	bq := client.New("", client.WithHTTPClient(rec.Client()), client.WithLogger(logger))
+	// the configured logger takes precedence, nothing is printed to stdout
+	bq.PrintDebug = true
+	return bq, logger
+}
+
+func TestLogCallCompleted(t *testing.T) {
+	bq, logger := loggedClient(t, "testdata/query_single_page.json")
+
+	if _, _, err := bq.Query("ds", "proj", "SELECT a FROM t"); err != nil {
+		t.Fatal(err)
+	}
+
+	e := logger.find("bigquery call completed", "jobs.query")
+	if e == nil {
+		t.Fatalf("logs = %+v, want the jobs.query call logged", logger.entries)
+	}
+	if e.level != "debug" {
+		t.Errorf("level = %s, want debug", e.level)
+	}
+	if e.args["project"] != "proj" || e.args["dataset"] != "ds" || e.args["job_id"] != "job_1" {
+		t.Errorf("args = %v", e.args)
+	}
+	if _, ok := e.args["latency"]; !ok {
+		t.Errorf("args = %v, want the latency", e.args)
+	}
+}
+
+func TestLogCallFailed(t *testing.T) {
+	bq, logger := loggedClient(t, "testdata/query_page_error.json")
+
+	_, _, err := bq.Query("ds", "proj", "SELECT a FROM t")
+	if err == nil {
+		t.Fatal("the page error was not returned")
+	}
+
+	e := logger.find("bigquery call failed", "jobs.getQueryResults")
+	if e == nil {
+		t.Fatalf("logs = %+v, want the failed call logged", logger.entries)
+	}
+	if e.level != "error" {
+		t.Errorf("level = %s, want error", e.level)
+	}
+	if e.args["job_id"] != "job_1" || e.args["err"] == nil {
+		t.Errorf("args = %v, want the job and the error", e.args)
+	}
+}
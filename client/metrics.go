@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+)
+
+// MetricsRecorder receives the throughput metrics of the client. Implementations must be safe for concurrent use, see
+// the prommetrics package for a Prometheus adapter and clienttest.Metrics for an in-memory recorder to assert on in tests
+type MetricsRecorder interface {
+	// QueryCompleted is called once per query, successful or not
+	QueryCompleted(m QueryMetrics)
+	// PageFetched is called for every page of results loaded from the API
+	PageFetched(rows int)
+	// RowsDecoded is called with the number of rows converted from the API representation
+	RowsDecoded(rows int)
+	// RowsInserted is called after every streaming insert with the rows accepted and the size of the request sent
+	RowsInserted(projectID, datasetID, tableID string, rows, bytes int)
+	// InsertFailed is called with the number of rows of a streaming insert rejected for reason
+	InsertFailed(projectID, datasetID, tableID, reason string, rows int)
+	// Retried is called every time an API call is retried because of reason
+	Retried(call, reason string)
+}
+
+// QueryMetrics describes a completed query
+type QueryMetrics struct {
+	ProjectID string
+	DatasetID string
+	Labels    map[string]string // the job labels of the query, see QueryOptions.Labels
+	Duration  time.Duration
+	CacheHit  bool
+	Err       error // nil if the query succeeded
+
+	BytesProcessed int64
+	BytesBilled    int64 // zero for queries paged through inserted jobs, whose results do not carry it
+}
+
+// WithMetrics is a configuration function that sets the recorder receiving the metrics of the client
+//
+// An example use is:
+//
+// client.New(pemPath, client.WithMetrics(prommetrics.MustNew(prometheus.DefaultRegisterer)))
+func WithMetrics(r MetricsRecorder) func(*Client) error {
+	return func(c *Client) error {
+		c.metricsRecorder = r
+		return nil
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) QueryCompleted(m QueryMetrics)                                       {}
+func (nopMetrics) PageFetched(rows int)                                                {}
+func (nopMetrics) RowsDecoded(rows int)                                                {}
+func (nopMetrics) RowsInserted(projectID, datasetID, tableID string, rows, bytes int)  {}
+func (nopMetrics) InsertFailed(projectID, datasetID, tableID, reason string, rows int) {}
+func (nopMetrics) Retried(call, reason string)                                         {}
+
+// metrics returns the configured recorder or one discarding everything
+func (c *Client) metrics() MetricsRecorder {
+	if c.metricsRecorder != nil {
+		return c.metricsRecorder
+	}
+	return nopMetrics{}
+}
+
+// recordQuery reports a query that started at start with the statistics the client has at hand, nil if none
+func (c *Client) recordQuery(projectID, datasetID string, opts QueryOptions, start time.Time, stats *QueryStats, err error) {
+	if c.metricsRecorder == nil {
+		return
+	}
+
+	m := QueryMetrics{
+		ProjectID: projectID,
+		DatasetID: datasetID,
+		Labels:    opts.Labels,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+
+	if stats != nil {
+		m.BytesProcessed = stats.TotalBytesProcessed
+		m.BytesBilled = stats.TotalBytesBilled
+		m.CacheHit = stats.CacheHit
+	}
+
+	c.metricsRecorder.QueryCompleted(m)
+}
+
+// recordInsert reports the outcome of a streaming insert of rows, sent in a request body of size bytes. Rows rejected are
+// grouped by the reason of their first error
+func (c *Client) recordInsert(projectID, datasetID, tableID string, rows int, size int64, resp *bigquery.TableDataInsertAllResponse, err error) {
+	if c.metricsRecorder == nil {
+		return
+	}
+
+	if err != nil {
+		c.metricsRecorder.InsertFailed(projectID, datasetID, tableID, errorReason(err), rows)
+		return
+	}
+
+	failed := map[string]int{}
+	for _, rowErr := range resp.InsertErrors {
+		reason := "unknown"
+		if len(rowErr.Errors) > 0 && rowErr.Errors[0].Reason != "" {
+			reason = rowErr.Errors[0].Reason
+		}
+		failed[reason]++
+	}
+	for reason, rows := range failed {
+		c.metricsRecorder.InsertFailed(projectID, datasetID, tableID, reason, rows)
+	}
+
+	c.metricsRecorder.RowsInserted(projectID, datasetID, tableID, rows-len(resp.InsertErrors), int(size))
+}
+
+// errorReason returns a short, low cardinality reason for err suitable as a metric label
+func errorReason(err error) string {
+	var conflict *ConflictError
+	var jobWait *JobWaitError
+	var apiErr *googleapi.Error
+	switch {
+	case errors.As(err, &conflict):
+		return "conflict"
+	case errors.As(err, &jobWait):
+		return "job_wait"
+	case errors.As(err, &apiErr):
+		if len(apiErr.Errors) > 0 && apiErr.Errors[0].Reason != "" {
+			return apiErr.Errors[0].Reason
+		}
+		return "http_" + strconv.Itoa(apiErr.Code)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "unknown"
+}
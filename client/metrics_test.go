@@ -0,0 +1,130 @@
+package client_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+)
+
+// callCounter is an interceptor counting the API calls by method and keeping their URLs, and the headers and bodies
+// of the calls by method
+type callCounter struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	urls    []*url.URL
+	headers map[string][]http.Header
+	bodies  map[string][]string
+	sent    int64
+}
+
+func (cc *callCounter) intercept(method string, req *http.Request, next client.Invoker) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	cc.mu.Lock()
+	if cc.calls == nil {
+		cc.calls = map[string]int{}
+		cc.headers = map[string][]http.Header{}
+		cc.bodies = map[string][]string{}
+	}
+	cc.calls[method]++
+	cc.urls = append(cc.urls, req.URL)
+	cc.headers[method] = append(cc.headers[method], req.Header.Clone())
+	cc.bodies[method] = append(cc.bodies[method], string(body))
+	cc.sent += req.ContentLength
+	cc.mu.Unlock()
+	return next(req)
+}
+
+func meteredClient(t *testing.T, golden string) (*client.Client, *clienttest.Metrics, *callCounter) {
+	t.Helper()
+
+	rec, err := clienttest.NewRecorder(golden, clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	metrics := clienttest.NewMetrics()
+	calls := &callCounter{}
+	bq := client.New("", client.WithHTTPClient(rec.Client()), client.WithMetrics(metrics), client.WithInterceptors(calls.intercept))
+	return bq, metrics, calls
+}
+
+func TestQueryMetrics(t *testing.T) {
+	bq, metrics, calls := meteredClient(t, "testdata/query_single_page.json")
+
+	if _, _, err := bq.Query("", "proj", "SELECT a FROM t"); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := metrics.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("%d queries recorded, want 1", len(queries))
+	}
+	if q := queries[0]; q.BytesProcessed != 1024 || !q.CacheHit || q.ProjectID != "proj" || q.Err != nil {
+		t.Errorf("unexpected query metrics %+v", q)
+	}
+	if n := calls.calls["jobs.get"]; n != 0 {
+		t.Errorf("%d jobs.get calls, the statistics of the response should be used", n)
+	}
+	if metrics.Pages() != 1 || metrics.DecodedRows() != 2 {
+		t.Errorf("pages = %d, rows = %d, want 1 and 2", metrics.Pages(), metrics.DecodedRows())
+	}
+}
+
+func TestSyncQueryMetrics(t *testing.T) {
+	bq, metrics, _ := meteredClient(t, "testdata/query_billed.json")
+
+	if _, err := bq.SyncQuery("", "proj", "SELECT a FROM t", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := metrics.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("%d queries recorded, want 1", len(queries))
+	}
+	if q := queries[0]; q.BytesProcessed != 2048 || q.BytesBilled != 10485760 || q.CacheHit {
+		t.Errorf("unexpected query metrics %+v", q)
+	}
+	if metrics.BytesBilled() != 10485760 {
+		t.Errorf("bytes billed = %d, want 10485760", metrics.BytesBilled())
+	}
+}
+
+func TestInsertMetrics(t *testing.T) {
+	bq, metrics, calls := meteredClient(t, "testdata/insert_rows.json")
+
+	rows := []map[string]interface{}{{"id": 1}, {"id": 2, "bogus": true}}
+	if err := bq.InsertRows("proj", "ds", "events", rows); err == nil {
+		t.Fatal("expected the insert errors to be returned")
+	}
+
+	inserted, bytes := metrics.Inserted("proj", "ds", "events")
+	if inserted != 1 {
+		t.Errorf("%d rows inserted, want 1", inserted)
+	}
+	if bytes <= 0 || int64(bytes) != calls.sent {
+		t.Errorf("%d bytes recorded, want the %d bytes of the request body", bytes, calls.sent)
+	}
+	if failed := metrics.InsertFailures("invalid"); failed != 1 {
+		t.Errorf("%d rows failed, want 1", failed)
+	}
+}
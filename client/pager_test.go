@@ -0,0 +1,138 @@
+package client_test
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+	"google.golang.org/api/googleapi"
+)
+
+func replayClient(t *testing.T, golden string) *client.Client {
+	t.Helper()
+
+	rec, err := clienttest.NewRecorder(golden, clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return client.New("", client.WithHTTPClient(rec.Client()))
+}
+
+func TestQueryPaging(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		want   [][]interface{}
+	}{
+		{"single page", "testdata/query_single_page.json", [][]interface{}{{"x"}, {"y"}}},
+		{"multiple pages", "testdata/query_multi_page.json", [][]interface{}{{"x"}, {"y"}, {"z"}, {"w"}}},
+		{"job not complete", "testdata/query_pending.json", [][]interface{}{{"x"}, {"y"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bq := replayClient(t, tt.golden)
+
+			rows, headers, err := bq.Query("", "proj", "SELECT a FROM t")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(headers, []string{"a"}) {
+				t.Errorf("headers = %v, want [a]", headers)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("rows = %v, want %v", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsyncQueryPaging(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		want   [][]interface{}
+	}{
+		{"single page", "testdata/query_single_page.json", [][]interface{}{{"x"}, {"y"}}},
+		{"multiple pages", "testdata/query_multi_page.json", [][]interface{}{{"x"}, {"y"}, {"z"}, {"w"}}},
+		{"job not complete", "testdata/query_pending.json", [][]interface{}{{"x"}, {"y"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bq := replayClient(t, tt.golden)
+
+			dataChan := make(chan client.Data, 10)
+			go bq.AsyncQuery(100, "", "proj", "SELECT a FROM t", dataChan)
+
+			var rows [][]interface{}
+			for d := range dataChan {
+				if d.Err != nil {
+					t.Fatal(d.Err)
+				}
+				rows = append(rows, d.Rows...)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("rows = %v, want %v", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryPagingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		golden  string
+		options []func(*client.Client) error
+		check   func(error) bool
+	}{
+		{
+			"max job wait", "testdata/query_job_wait.json",
+			[]func(*client.Client) error{client.MaxJobWait(time.Nanosecond)},
+			func(err error) bool {
+				var waitErr *client.JobWaitError
+				return errors.As(err, &waitErr) && waitErr.JobID == "job_1"
+			},
+		},
+		{
+			"page error", "testdata/query_page_error.json", nil,
+			func(err error) bool {
+				var apiErr *googleapi.Error
+				return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := clienttest.NewRecorder(tt.golden, clienttest.Replay, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rec.Close()
+			bq := client.New("", append(tt.options, client.WithHTTPClient(rec.Client()))...)
+
+			dataChan := make(chan client.Data, 10)
+			go bq.AsyncQuery(100, "", "proj", "SELECT a FROM t", dataChan)
+
+			var errs []error
+			for d := range dataChan {
+				if d.Err != nil {
+					errs = append(errs, d.Err)
+				}
+			}
+			if len(errs) != 1 || !tt.check(errs[0]) {
+				t.Errorf("errors = %v, want a single expected error", errs)
+			}
+		})
+	}
+}
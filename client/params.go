@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// QueryParameter is a named query parameter, referenced as @Name in standard SQL. Parameters passed as plain values
+// are positional and referenced as ? in the order they are given
+type QueryParameter struct {
+	Name  string
+	Value interface{}
+}
+
+// toBigQueryParams converts the parameters and returns the parameter mode they require, named and positional
+// parameters can not be mixed
+func toBigQueryParams(params []interface{}) ([]*bigquery.QueryParameter, string, error) {
+	if len(params) == 0 {
+		return nil, "", nil
+	}
+
+	var mode string
+	bqParams := make([]*bigquery.QueryParameter, len(params))
+	for i, p := range params {
+		name, value, paramMode := "", p, "POSITIONAL"
+		if qp, ok := p.(QueryParameter); ok {
+			name, value, paramMode = qp.Name, qp.Value, "NAMED"
+		}
+
+		if len(mode) > 0 && mode != paramMode {
+			return nil, "", fmt.Errorf("named and positional query parameters can not be mixed")
+		}
+		mode = paramMode
+
+		paramType, paramValue, err := queryParamTypeAndValue(reflect.ValueOf(value))
+		if err != nil {
+			return nil, "", fmt.Errorf("query parameter %d: %v", i, err)
+		}
+
+		bqParams[i] = &bigquery.QueryParameter{Name: name, ParameterType: paramType, ParameterValue: paramValue}
+	}
+
+	return bqParams, mode, nil
+}
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+func queryParamTypeAndValue(v reflect.Value) (*bigquery.QueryParameterType, *bigquery.QueryParameterValue, error) {
+	if !v.IsValid() {
+		return nil, nil, fmt.Errorf("nil values have no type, use a typed zero value")
+	}
+
+	if v.Kind() == reflect.Slice && v.Type() != bytesType {
+		elemType, err := queryParamType(v.Type().Elem())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := &bigquery.QueryParameterValue{ArrayValues: []*bigquery.QueryParameterValue{}}
+		for i := 0; i < v.Len(); i++ {
+			_, ev, err := queryParamTypeAndValue(v.Index(i))
+			if err != nil {
+				return nil, nil, err
+			}
+			value.ArrayValues = append(value.ArrayValues, ev)
+		}
+		return &bigquery.QueryParameterType{Type: "ARRAY", ArrayType: elemType}, value, nil
+	}
+
+	paramType, err := queryParamType(v.Type())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var s string
+	switch x := v.Interface().(type) {
+	case time.Time:
+		s = x.UTC().Format("2006-01-02 15:04:05.999999-07:00")
+	case []byte:
+		s = base64.StdEncoding.EncodeToString(x)
+	default:
+		switch v.Kind() {
+		case reflect.String:
+			s = v.String()
+		case reflect.Bool:
+			s = strconv.FormatBool(v.Bool())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s = strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = strconv.FormatUint(v.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			s = strconv.FormatFloat(v.Float(), 'g', -1, 64)
+		}
+	}
+
+	return paramType, &bigquery.QueryParameterValue{Value: s}, nil
+}
+
+func queryParamType(t reflect.Type) (*bigquery.QueryParameterType, error) {
+	switch {
+	case t == timeType:
+		return &bigquery.QueryParameterType{Type: "TIMESTAMP"}, nil
+	case t == bytesType:
+		return &bigquery.QueryParameterType{Type: "BYTES"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &bigquery.QueryParameterType{Type: "STRING"}, nil
+	case reflect.Bool:
+		return &bigquery.QueryParameterType{Type: "BOOL"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &bigquery.QueryParameterType{Type: "INT64"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &bigquery.QueryParameterType{Type: "FLOAT64"}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported query parameter type %s", t)
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func TestToBigQueryParams(t *testing.T) {
+	scalar := func(name, typ, value string) *bigquery.QueryParameter {
+		return &bigquery.QueryParameter{
+			Name:           name,
+			ParameterType:  &bigquery.QueryParameterType{Type: typ},
+			ParameterValue: &bigquery.QueryParameterValue{Value: value},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		params   []interface{}
+		want     []*bigquery.QueryParameter
+		wantMode string
+	}{
+		{"none", nil, nil, ""},
+		{
+			"positional scalars",
+			[]interface{}{"a", int64(3), 1.5, true, uint8(7)},
+			[]*bigquery.QueryParameter{
+				scalar("", "STRING", "a"),
+				scalar("", "INT64", "3"),
+				scalar("", "FLOAT64", "1.5"),
+				scalar("", "BOOL", "true"),
+				scalar("", "INT64", "7"),
+			},
+			"POSITIONAL",
+		},
+		{
+			"named timestamp and bytes",
+			[]interface{}{
+				QueryParameter{Name: "at", Value: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.FixedZone("CET", 3600))},
+				QueryParameter{Name: "data", Value: []byte("hi")},
+			},
+			[]*bigquery.QueryParameter{
+				scalar("at", "TIMESTAMP", "2024-01-02 02:04:05.000006+00:00"),
+				scalar("data", "BYTES", "aGk="),
+			},
+			"NAMED",
+		},
+		{
+			"array",
+			[]interface{}{QueryParameter{Name: "ids", Value: []int64{1, 2}}},
+			[]*bigquery.QueryParameter{{
+				Name: "ids",
+				ParameterType: &bigquery.QueryParameterType{
+					Type:      "ARRAY",
+					ArrayType: &bigquery.QueryParameterType{Type: "INT64"},
+				},
+				ParameterValue: &bigquery.QueryParameterValue{ArrayValues: []*bigquery.QueryParameterValue{
+					{Value: "1"},
+					{Value: "2"},
+				}},
+			}},
+			"NAMED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, mode, err := toBigQueryParams(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode != tt.wantMode {
+				t.Errorf("mode = %q, want %q", mode, tt.wantMode)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %s, want %s", paramsString(got), paramsString(tt.want))
+			}
+		})
+	}
+}
+
+func TestToBigQueryParamsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []interface{}
+		want   string
+	}{
+		{"mixed modes", []interface{}{1, QueryParameter{Name: "a", Value: 1}}, "can not be mixed"},
+		{"nil value", []interface{}{nil}, "nil values have no type"},
+		{"unsupported type", []interface{}{map[string]int{}}, "query parameter 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := toBigQueryParams(tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func paramsString(params []*bigquery.QueryParameter) string {
+	var parts []string
+	for _, p := range params {
+		b, _ := p.MarshalJSON()
+		parts = append(parts, string(b))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,159 @@
+// Package prommetrics records the metrics of a bigquery client with Prometheus
+//
+// An example use is:
+//
+// bqClient := client.New(pemPath, client.WithMetrics(prommetrics.MustNew(prometheus.DefaultRegisterer, "team")))
+package prommetrics
+
+import (
+	"github.com/dailyburn/bigquery/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "bigquery"
+
+// Recorder is a client.MetricsRecorder exporting the client metrics as Prometheus collectors
+type Recorder struct {
+	jobLabels []string
+
+	queries        *prometheus.CounterVec
+	queryDuration  *prometheus.HistogramVec
+	bytesBilled    *prometheus.CounterVec
+	bytesProcessed *prometheus.CounterVec
+	pages          prometheus.Counter
+	rowsDecoded    prometheus.Counter
+	insertRows     *prometheus.CounterVec
+	insertBytes    *prometheus.CounterVec
+	insertFailures *prometheus.CounterVec
+	retries        *prometheus.CounterVec
+}
+
+var _ client.MetricsRecorder = (*Recorder)(nil)
+
+// New creates a recorder and registers its collectors with reg. The query metrics are labelled by project, status
+// (ok or error) and by the values of the given job label keys, see client.QueryOptions.Labels. Only pass keys with a
+// small set of values, e.g. team or dashboard, as every combination is a time series. The insert metrics are labelled by
+// project and dataset, not by table, for the same reason
+func New(reg prometheus.Registerer, jobLabels ...string) (*Recorder, error) {
+	queryLabels := append([]string{"project", "status"}, jobLabels...)
+	datasetLabels := []string{"project", "dataset"}
+
+	r := &Recorder{
+		jobLabels: jobLabels,
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "queries_total",
+			Help:      "Number of queries run.",
+		}, queryLabels),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "query_duration_seconds",
+			Help:      "Duration of the queries, including paging over their results.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+		}, queryLabels),
+		bytesBilled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "query_bytes_billed_total",
+			Help:      "Bytes billed for the queries run.",
+		}, queryLabels),
+		bytesProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "query_bytes_processed_total",
+			Help:      "Bytes processed by the queries run.",
+		}, queryLabels),
+		pages: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "result_pages_total",
+			Help:      "Pages of query results fetched.",
+		}),
+		rowsDecoded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rows_decoded_total",
+			Help:      "Rows of query results decoded.",
+		}),
+		insertRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "insert_rows_total",
+			Help:      "Rows accepted by streaming inserts.",
+		}, datasetLabels),
+		insertBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "insert_bytes_total",
+			Help:      "Bytes sent by streaming inserts.",
+		}, datasetLabels),
+		insertFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "insert_failed_rows_total",
+			Help:      "Rows rejected by streaming inserts by reason.",
+		}, append(datasetLabels, "reason")),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "API calls retried by call and error reason.",
+		}, []string{"call", "reason"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		r.queries, r.queryDuration, r.bytesBilled, r.bytesProcessed, r.pages, r.rowsDecoded,
+		r.insertRows, r.insertBytes, r.insertFailures, r.retries,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// MustNew is like New but panics if the collectors can not be registered
+func MustNew(reg prometheus.Registerer, jobLabels ...string) *Recorder {
+	r, err := New(reg, jobLabels...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// QueryCompleted implements client.MetricsRecorder
+func (r *Recorder) QueryCompleted(m client.QueryMetrics) {
+	status := "ok"
+	if m.Err != nil {
+		status = "error"
+	}
+
+	values := append([]string{m.ProjectID, status}, make([]string, len(r.jobLabels))...)
+	for i, k := range r.jobLabels {
+		values[2+i] = m.Labels[k]
+	}
+
+	r.queries.WithLabelValues(values...).Inc()
+	r.queryDuration.WithLabelValues(values...).Observe(m.Duration.Seconds())
+	r.bytesBilled.WithLabelValues(values...).Add(float64(m.BytesBilled))
+	r.bytesProcessed.WithLabelValues(values...).Add(float64(m.BytesProcessed))
+}
+
+// PageFetched implements client.MetricsRecorder
+func (r *Recorder) PageFetched(rows int) {
+	r.pages.Inc()
+}
+
+// RowsDecoded implements client.MetricsRecorder
+func (r *Recorder) RowsDecoded(rows int) {
+	r.rowsDecoded.Add(float64(rows))
+}
+
+// RowsInserted implements client.MetricsRecorder
+func (r *Recorder) RowsInserted(projectID, datasetID, tableID string, rows, bytes int) {
+	r.insertRows.WithLabelValues(projectID, datasetID).Add(float64(rows))
+	r.insertBytes.WithLabelValues(projectID, datasetID).Add(float64(bytes))
+}
+
+// InsertFailed implements client.MetricsRecorder
+func (r *Recorder) InsertFailed(projectID, datasetID, tableID, reason string, rows int) {
+	r.insertFailures.WithLabelValues(projectID, datasetID, reason).Add(float64(rows))
+}
+
+// Retried implements client.MetricsRecorder
+func (r *Recorder) Retried(call, reason string) {
+	r.retries.WithLabelValues(call, reason).Inc()
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Write dispositions for QueryJobConfig
+const (
+	WriteAppend   = "WRITE_APPEND"
+	WriteTruncate = "WRITE_TRUNCATE"
+	WriteEmpty    = "WRITE_EMPTY"
+)
+
+// Create dispositions for QueryJobConfig
+const (
+	CreateIfNeeded = "CREATE_IF_NEEDED"
+	CreateNever    = "CREATE_NEVER"
+)
+
+// Schema update options for QueryJobConfig
+const (
+	AllowFieldAddition   = "ALLOW_FIELD_ADDITION"
+	AllowFieldRelaxation = "ALLOW_FIELD_RELAXATION"
+)
+
+const minJobPollInterval = 200 * time.Millisecond
+const maxJobPollInterval = 5 * time.Second
+
+// QueryJobConfig configures the job run by QueryToTable and how it writes the query results to its destination table
+type QueryJobConfig struct {
+	QueryOptions
+
+	WriteDisposition    string   // WRITE_APPEND, WRITE_TRUNCATE or WRITE_EMPTY, defaults to WRITE_EMPTY
+	CreateDisposition   string   // CREATE_IF_NEEDED or CREATE_NEVER, defaults to CREATE_IF_NEEDED
+	SchemaUpdateOptions []string // ALLOW_FIELD_ADDITION and/or ALLOW_FIELD_RELAXATION, for appends and partition overwrites
+
+	// DestinationOptions partition and cluster the destination table when the job creates it,
+	// see TimePartitioning, RangePartitioning and Clustering
+	DestinationOptions []TableOption
+}
+
+// QueryToTable runs the query and writes its results to the destination table without reading them back, waiting for
+// the job to complete and returning its statistics. tableID may carry a partition decorator, e.g. events$20240101, to
+// write a single partition
+func (c *Client) QueryToTable(ctx context.Context, queryStr, projectID, datasetID, tableID string, config QueryJobConfig) (*QueryStats, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config.QueryOptions = c.queryOptions.merge(config.QueryOptions)
+	dst := &bigquery.TableReference{ProjectId: projectID, DatasetId: datasetID, TableId: tableID}
+	job, err := newQueryJob(queryStr, projectID, datasetID, dst, config)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	ac := c.startCall(ctx, "jobs.insert", "project", projectID, "dataset", datasetID, "table", tableID)
+	job, err = service.Jobs.Insert(projectID, job).Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		c.recordQuery(projectID, datasetID, config.QueryOptions, start, nil, err)
+		return nil, err
+	}
+	ac.end(nil, "job_id", jobID(job.JobReference))
+
+	job, err = c.waitForJob(ctx, service, job.JobReference)
+	stats := queryStatsFromJob(job)
+	c.recordQuery(projectID, datasetID, config.QueryOptions, start, stats, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// newQueryJob builds a query job writing its results to dst, or to an anonymous table if dst is nil, using dataset as
+// the default dataset of the query
+func newQueryJob(queryStr, project, dataset string, dst *bigquery.TableReference, config QueryJobConfig) (*bigquery.Job, error) {
+	// apply the destination options to a scratch table to reuse the table creation options
+	destination := &bigquery.Table{}
+	for _, option := range config.DestinationOptions {
+		err := option(destination)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if destination.TimePartitioning != nil && destination.RangePartitioning != nil {
+		return nil, fmt.Errorf("table %s can not use both time and range partitioning", dst.TableId)
+	}
+
+	jobConfigQuery := &bigquery.JobConfigurationQuery{
+		Query:               queryStr,
+		AllowLargeResults:   dst != nil && !config.standardSQL(),
+		DestinationTable:    dst,
+		DefaultDataset:      defaultDataset(project, dataset),
+		WriteDisposition:    config.WriteDisposition,
+		CreateDisposition:   config.CreateDisposition,
+		SchemaUpdateOptions: config.SchemaUpdateOptions,
+		TimePartitioning:    destination.TimePartitioning,
+		RangePartitioning:   destination.RangePartitioning,
+		Clustering:          destination.Clustering,
+	}
+
+	job := &bigquery.Job{Configuration: &bigquery.JobConfiguration{Query: jobConfigQuery}}
+	err := config.applyToJob(job, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// waitForJob polls the job with an increasing interval until it is done, ctx is cancelled or the max wait is reached,
+// returning the completed job or the error it failed with
+func (c *Client) waitForJob(ctx context.Context, service *bigquery.Service, jobRef *bigquery.JobReference) (job *bigquery.Job, err error) {
+	ctx, span := c.startSpan(ctx, "wait_for_job", "project", jobRef.ProjectId, "job_id", jobRef.JobId)
+	polls := 0
+	defer func() {
+		endSpan(span, err, "polls", polls, "bytes_processed", bytesProcessed(job))
+	}()
+
+	start := time.Now()
+	interval := minJobPollInterval
+	for polls = 1; ; polls++ {
+		call := service.Jobs.Get(jobRef.ProjectId, jobRef.JobId)
+		if len(jobRef.Location) > 0 {
+			call.Location(jobRef.Location)
+		}
+
+		ac := c.startCall(ctx, "jobs.get", "project", jobRef.ProjectId, "job_id", jobRef.JobId)
+		job, err = call.Context(ac.ctx).Do()
+		ac.end(err, "attempt", polls)
+		if err != nil {
+			return nil, err
+		}
+
+		var state string
+		if job.Status != nil {
+			state = job.Status.State
+		}
+		if state == "DONE" {
+			if job.Status.ErrorResult != nil {
+				return job, fmt.Errorf("job %s failed: %s", jobRef.JobId, job.Status.ErrorResult.Message)
+			}
+			return job, nil
+		}
+
+		if c.maxJobWait > 0 && time.Since(start) >= c.maxJobWait {
+			return nil, &JobWaitError{JobID: jobRef.JobId, Waited: time.Since(start)}
+		}
+
+		c.log().Debug("waiting for job", "job_id", jobRef.JobId, "state", state, "interval", interval)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > maxJobPollInterval {
+			interval = maxJobPollInterval
+		}
+	}
+}
+
+// bytesProcessed returns the bytes processed by the job so far, zero if the job or its statistics are missing
+func bytesProcessed(job *bigquery.Job) int64 {
+	if job == nil || job.Statistics == nil {
+		return 0
+	}
+	return job.Statistics.TotalBytesProcessed
+}
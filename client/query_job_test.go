@@ -0,0 +1,77 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func TestQueryToTable(t *testing.T) {
+	rec, err := clienttest.NewRecorder("testdata/query_to_table.json", clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+
+	var inserted bigquery.Job
+	calls := &callCounter{}
+	capture := func(method string, req *http.Request, next client.Invoker) (*http.Response, error) {
+		if method == "jobs.insert" {
+			b, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(b))
+			if err = json.Unmarshal(b, &inserted); err != nil {
+				return nil, err
+			}
+		}
+		return next(req)
+	}
+	metrics := clienttest.NewMetrics()
+	bq := client.New("", client.WithHTTPClient(rec.Client()), client.WithMetrics(metrics), client.WithInterceptors(calls.intercept, capture))
+
+	stats, err := bq.QueryToTable(context.Background(), "SELECT a FROM t", "proj", "ds", "dst", client.QueryJobConfig{
+		WriteDisposition: client.WriteTruncate,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.JobID != "job_1" || stats.TotalBytesProcessed != 4096 || stats.TotalBytesBilled != 10485760 || stats.StatementType != "SELECT" {
+		t.Errorf("unexpected statistics %+v", stats)
+	}
+	// the first jobs.get response carries no status
+	if n := calls.calls["jobs.get"]; n != 2 {
+		t.Errorf("%d jobs.get calls, want 2", n)
+	}
+
+	q := inserted.Configuration.Query
+	if q.DestinationTable.TableId != "dst" || q.WriteDisposition != client.WriteTruncate || q.DefaultDataset.DatasetId != "ds" {
+		t.Errorf("unexpected query job %+v", q)
+	}
+
+	if err = rec.Close(); err != nil {
+		t.Error(err)
+	}
+	if queries := metrics.Queries(); len(queries) != 1 || queries[0].BytesBilled != 10485760 {
+		t.Errorf("unexpected query metrics %+v", queries)
+	}
+}
+
+func TestQueryToTableFailed(t *testing.T) {
+	bq := replayClient(t, "testdata/query_to_table_failed.json")
+
+	_, err := bq.QueryToTable(context.Background(), "SELECT a FROM t", "proj", "ds", "dst", client.QueryJobConfig{})
+	if err == nil || !strings.Contains(err.Error(), "Already Exists") {
+		t.Errorf("err = %v, want the job error", err)
+	}
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Query priorities for QueryOptions
+const (
+	PriorityInteractive = "INTERACTIVE"
+	PriorityBatch       = "BATCH"
+)
+
+// QueryOptions configures the jobs run by the query entry points: Query, AsyncQuery, SyncQuery and QueryToTable.
+// Options set on the client with DefaultQueryOptions apply to every query, options passed to a call override them
+// field by field and their labels are added to the default labels. The bool options are pointers, set with Bool, so
+// that a call can override a default with false
+type QueryOptions struct {
+	Priority           string            // INTERACTIVE (default) or BATCH
+	Labels             map[string]string // job labels, e.g. for cost attribution by team
+	MaximumBytesBilled int64             // the job fails without being billed if it would bill more, zero means no limit
+	UseQueryCache      *bool             // read results from, and write them to, the query cache, unset uses it
+	JobTimeout         time.Duration     // the job is cancelled if it runs longer, zero means no timeout
+	Location           string            // the location (e.g. US, EU or a region) the job runs in
+	JobIDPrefix        string            // prepended to a unique suffix to build the job ID
+	UseStandardSQL     *bool             // queries use legacy SQL unless set to true
+
+	// Parameters are bound to the query like the params of Exec, they require UseStandardSQL
+	Parameters []interface{}
+}
+
+// Bool returns a pointer to v, for the bool fields of QueryOptions
+func Bool(v bool) *bool {
+	return &v
+}
+
+// DefaultQueryOptions is a configuration function that sets the options applied to every query run by the client
+func DefaultQueryOptions(opts QueryOptions) func(*Client) error {
+	return func(c *Client) error {
+		c.queryOptions = opts
+		return nil
+	}
+}
+
+// mergeQueryOptions returns the client defaults merged with the options passed to a query entry point
+func (c *Client) mergeQueryOptions(opts []QueryOptions) QueryOptions {
+	return c.queryOptions.merge(opts...)
+}
+
+// merge returns o with the non-zero fields of the overrides applied in order, labels are combined and the bool fields
+// set to true or false override
+func (o QueryOptions) merge(overrides ...QueryOptions) QueryOptions {
+	for _, ov := range overrides {
+		if len(ov.Priority) > 0 {
+			o.Priority = ov.Priority
+		}
+		if len(ov.Labels) > 0 {
+			labels := make(map[string]string, len(o.Labels)+len(ov.Labels))
+			for k, v := range o.Labels {
+				labels[k] = v
+			}
+			for k, v := range ov.Labels {
+				labels[k] = v
+			}
+			o.Labels = labels
+		}
+		if ov.MaximumBytesBilled > 0 {
+			o.MaximumBytesBilled = ov.MaximumBytesBilled
+		}
+		if ov.JobTimeout > 0 {
+			o.JobTimeout = ov.JobTimeout
+		}
+		if len(ov.Location) > 0 {
+			o.Location = ov.Location
+		}
+		if len(ov.JobIDPrefix) > 0 {
+			o.JobIDPrefix = ov.JobIDPrefix
+		}
+		if len(ov.Parameters) > 0 {
+			o.Parameters = ov.Parameters
+		}
+		if ov.UseQueryCache != nil {
+			o.UseQueryCache = ov.UseQueryCache
+		}
+		if ov.UseStandardSQL != nil {
+			o.UseStandardSQL = ov.UseStandardSQL
+		}
+	}
+	return o
+}
+
+// needsJob reports whether the options can only be expressed by inserting a job rather than with jobs.query
+func (o QueryOptions) needsJob() bool {
+	return strings.ToUpper(o.Priority) == PriorityBatch || o.JobTimeout > 0 || len(o.JobIDPrefix) > 0
+}
+
+// applyToRequest sets the options supported by jobs.query on the request
+func (o QueryOptions) applyToRequest(query *bigquery.QueryRequest) error {
+	params, mode, err := o.queryParameters()
+	if err != nil {
+		return err
+	}
+
+	query.Labels = o.Labels
+	query.MaximumBytesBilled = o.MaximumBytesBilled
+	query.Location = o.Location
+	query.UseQueryCache = o.UseQueryCache
+	query.UseLegacySql = o.useLegacySQL()
+	query.QueryParameters = params
+	query.ParameterMode = mode
+	return nil
+}
+
+// applyToJob sets the options on a query job to be inserted in project
+func (o QueryOptions) applyToJob(job *bigquery.Job, project string) error {
+	params, mode, err := o.queryParameters()
+	if err != nil {
+		return err
+	}
+
+	job.Configuration.Labels = o.Labels
+	job.Configuration.JobTimeoutMs = int64(o.JobTimeout / time.Millisecond)
+
+	query := job.Configuration.Query
+	query.Priority = strings.ToUpper(o.Priority)
+	query.MaximumBytesBilled = o.MaximumBytesBilled
+	query.UseQueryCache = o.UseQueryCache
+	query.UseLegacySql = o.useLegacySQL()
+	query.QueryParameters = params
+	query.ParameterMode = mode
+
+	if len(o.JobIDPrefix) > 0 || len(o.Location) > 0 {
+		job.JobReference = &bigquery.JobReference{ProjectId: project, Location: o.Location}
+		if len(o.JobIDPrefix) > 0 {
+			suffix, err := uniqueSuffix()
+			if err != nil {
+				return err
+			}
+			job.JobReference.JobId = o.JobIDPrefix + "_" + suffix
+		}
+	}
+
+	return nil
+}
+
+// queryParameters converts the parameters, which are only supported by standard SQL
+func (o QueryOptions) queryParameters() ([]*bigquery.QueryParameter, string, error) {
+	if len(o.Parameters) > 0 && !o.standardSQL() {
+		return nil, "", fmt.Errorf("query parameters require standard SQL, set UseStandardSQL")
+	}
+	return toBigQueryParams(o.Parameters)
+}
+
+// standardSQL reports whether the query is standard SQL
+func (o QueryOptions) standardSQL() bool {
+	return o.UseStandardSQL != nil && *o.UseStandardSQL
+}
+
+func (o QueryOptions) useLegacySQL() *bool {
+	if o.UseStandardSQL == nil {
+		return nil
+	}
+	return Bool(!*o.UseStandardSQL)
+}
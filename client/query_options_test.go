@@ -0,0 +1,96 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func TestQueryOptionsMerge(t *testing.T) {
+	defaults := QueryOptions{
+		Priority:       PriorityBatch,
+		Labels:         map[string]string{"team": "data", "env": "prod"},
+		UseQueryCache:  Bool(false),
+		UseStandardSQL: Bool(true),
+		JobTimeout:     time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		override QueryOptions
+		want     QueryOptions
+	}{
+		{"no override", QueryOptions{}, defaults},
+		{
+			"fields override",
+			QueryOptions{Priority: PriorityInteractive, Location: "EU", Labels: map[string]string{"env": "dev", "job": "report"}},
+			QueryOptions{
+				Priority:       PriorityInteractive,
+				Labels:         map[string]string{"team": "data", "env": "dev", "job": "report"},
+				UseQueryCache:  Bool(false),
+				UseStandardSQL: Bool(true),
+				JobTimeout:     time.Minute,
+				Location:       "EU",
+			},
+		},
+		{
+			"bools override with false",
+			QueryOptions{UseQueryCache: Bool(true), UseStandardSQL: Bool(false)},
+			QueryOptions{
+				Priority:       PriorityBatch,
+				Labels:         map[string]string{"team": "data", "env": "prod"},
+				UseQueryCache:  Bool(true),
+				UseStandardSQL: Bool(false),
+				JobTimeout:     time.Minute,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaults.merge(tt.override); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if defaults.Labels["env"] != "prod" {
+		t.Error("the default labels were modified")
+	}
+}
+
+func TestQueryOptionsBools(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            QueryOptions
+		useQueryCache   *bool
+		useLegacySQL    *bool
+		paramsSupported bool
+	}{
+		{"unset", QueryOptions{}, nil, nil, false},
+		{"cache disabled, standard SQL", QueryOptions{UseQueryCache: Bool(false), UseStandardSQL: Bool(true)}, Bool(false), Bool(false), true},
+		{"cache enabled, legacy SQL", QueryOptions{UseQueryCache: Bool(true), UseStandardSQL: Bool(false)}, Bool(true), Bool(true), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &bigquery.QueryRequest{}
+			if err := tt.opts.applyToRequest(req); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(req.UseQueryCache, tt.useQueryCache) {
+				t.Errorf("UseQueryCache = %v, want %v", req.UseQueryCache, tt.useQueryCache)
+			}
+			if got := tt.opts.useLegacySQL(); !reflect.DeepEqual(got, tt.useLegacySQL) {
+				t.Errorf("useLegacySQL = %v, want %v", got, tt.useLegacySQL)
+			}
+
+			tt.opts.Parameters = []interface{}{int64(1)}
+			if _, _, err := tt.opts.queryParameters(); (err == nil) != tt.paramsSupported {
+				t.Errorf("queryParameters err = %v, parameters supported %v", err, tt.paramsSupported)
+			}
+		})
+	}
+}
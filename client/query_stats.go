@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// QueryStats are the statistics of a completed query job
+type QueryStats struct {
+	ProjectID string
+	JobID     string
+	Location  string
+
+	TotalBytesProcessed int64
+	TotalBytesBilled    int64
+	SlotMillis          int64
+	CacheHit            bool
+	StatementType       string // e.g. SELECT, INSERT, UPDATE, DELETE, MERGE
+	NumDMLAffectedRows  int64
+	InsertedRows        int64 // DML statements only
+	UpdatedRows         int64 // DML statements only
+	DeletedRows         int64 // DML statements only
+
+	CreationTime time.Time
+	StartTime    time.Time
+	EndTime      time.Time
+
+	QueryPlan        []QueryStage
+	Timeline         []QueryTimelineSample
+	ReferencedTables []string // in project:dataset.table form
+}
+
+// QueryStage is a single stage of the query execution plan
+type QueryStage struct {
+	ID             int64
+	Name           string
+	Status         string
+	RecordsRead    int64
+	RecordsWritten int64
+	SlotMillis     int64
+	Start          time.Time
+	End            time.Time
+}
+
+// QueryTimelineSample is a snapshot of the query progress taken while it was running
+type QueryTimelineSample struct {
+	Elapsed         time.Duration
+	ActiveUnits     int64
+	CompletedUnits  int64
+	PendingUnits    int64
+	TotalSlotMillis int64
+}
+
+// QueryWithStats loads the data for the query like Query and also returns the statistics of the query job
+func (c *Client) QueryWithStats(dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, *QueryStats, error) {
+	return c.QueryWithStatsContext(context.Background(), dataset, project, queryStr, opts...)
+}
+
+// QueryWithStatsContext is QueryWithStats running within ctx
+func (c *Client) QueryWithStatsContext(ctx context.Context, dataset, project, queryStr string, opts ...QueryOptions) ([][]interface{}, []string, *QueryStats, error) {
+	rows, headers, partial, err := c.pagedQuery(ctx, defaultPageSize, dataset, project, queryStr, nil, c.mergeQueryOptions(opts))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// the query responses only carry a few statistics, load the job for the rest
+	stats, err := c.jobStats(ctx, partial.jobReference())
+	if err != nil {
+		return rows, headers, nil, err
+	}
+
+	return rows, headers, stats, nil
+}
+
+// DryRun validates the query without running it and returns its statistics, TotalBytesProcessed is the number of
+// bytes the query would process and ReferencedTables the tables it would read
+func (c *Client) DryRun(ctx context.Context, dataset, project, queryStr string, opts ...QueryOptions) (*QueryStats, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := newQueryJob(queryStr, project, dataset, nil, QueryJobConfig{QueryOptions: c.mergeQueryOptions(opts)})
+	if err != nil {
+		return nil, err
+	}
+	job.Configuration.DryRun = true
+
+	ac := c.startCall(ctx, "jobs.insert", "project", project, "dataset", dataset, "dry_run", true)
+	job, err = service.Jobs.Insert(project, job).Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		return nil, err
+	}
+	stats := queryStatsFromJob(job)
+	ac.end(nil, "bytes_processed", stats.TotalBytesProcessed)
+
+	return stats, nil
+}
+
+// responseStats returns the statistics carried by the responses of jobs.query and jobs.getQueryResults, which lack the
+// timings and plan of the job statistics. Only jobs.query reports the bytes billed
+func responseStats(jobRef *bigquery.JobReference, bytesProcessed, bytesBilled int64, cacheHit bool) *QueryStats {
+	stats := &QueryStats{TotalBytesProcessed: bytesProcessed, TotalBytesBilled: bytesBilled, CacheHit: cacheHit}
+	if jobRef != nil {
+		stats.ProjectID = jobRef.ProjectId
+		stats.JobID = jobRef.JobId
+		stats.Location = jobRef.Location
+	}
+	return stats
+}
+
+// jobReference returns the reference of the job the statistics belong to, nil if unknown
+func (s *QueryStats) jobReference() *bigquery.JobReference {
+	if s == nil || len(s.JobID) == 0 {
+		return nil
+	}
+	return &bigquery.JobReference{ProjectId: s.ProjectID, JobId: s.JobID, Location: s.Location}
+}
+
+// jobStats loads the job and returns its statistics
+func (c *Client) jobStats(ctx context.Context, jobRef *bigquery.JobReference) (*QueryStats, error) {
+	if jobRef == nil {
+		return nil, fmt.Errorf("missing job reference")
+	}
+
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	call := service.Jobs.Get(jobRef.ProjectId, jobRef.JobId)
+	if len(jobRef.Location) > 0 {
+		call.Location(jobRef.Location)
+	}
+
+	ac := c.startCall(ctx, "jobs.get", "project", jobRef.ProjectId, "job_id", jobRef.JobId)
+	job, err := call.Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		return nil, err
+	}
+	ac.end(nil, "bytes_processed", bytesProcessed(job))
+
+	return queryStatsFromJob(job), nil
+}
+
+// queryStatsFromJob returns the statistics of the job, nil if job is nil
+func queryStatsFromJob(job *bigquery.Job) *QueryStats {
+	if job == nil {
+		return nil
+	}
+
+	stats := &QueryStats{}
+	if job.JobReference != nil {
+		stats.ProjectID = job.JobReference.ProjectId
+		stats.JobID = job.JobReference.JobId
+		stats.Location = job.JobReference.Location
+	}
+
+	js := job.Statistics
+	if js == nil {
+		return stats
+	}
+
+	stats.TotalBytesProcessed = js.TotalBytesProcessed
+	stats.SlotMillis = js.TotalSlotMs
+	stats.CreationTime = msToTime(js.CreationTime)
+	stats.StartTime = msToTime(js.StartTime)
+	stats.EndTime = msToTime(js.EndTime)
+
+	q := js.Query
+	if q == nil {
+		return stats
+	}
+
+	stats.TotalBytesBilled = q.TotalBytesBilled
+	stats.CacheHit = q.CacheHit
+	stats.StatementType = q.StatementType
+	stats.NumDMLAffectedRows = q.NumDmlAffectedRows
+	if q.DmlStats != nil {
+		stats.InsertedRows = q.DmlStats.InsertedRowCount
+		stats.UpdatedRows = q.DmlStats.UpdatedRowCount
+		stats.DeletedRows = q.DmlStats.DeletedRowCount
+	}
+
+	for _, s := range q.QueryPlan {
+		stats.QueryPlan = append(stats.QueryPlan, QueryStage{
+			ID:             s.Id,
+			Name:           s.Name,
+			Status:         s.Status,
+			RecordsRead:    s.RecordsRead,
+			RecordsWritten: s.RecordsWritten,
+			SlotMillis:     s.SlotMs,
+			Start:          msToTime(s.StartMs),
+			End:            msToTime(s.EndMs),
+		})
+	}
+
+	for _, t := range q.Timeline {
+		stats.Timeline = append(stats.Timeline, QueryTimelineSample{
+			Elapsed:         time.Duration(t.ElapsedMs) * time.Millisecond,
+			ActiveUnits:     t.ActiveUnits,
+			CompletedUnits:  t.CompletedUnits,
+			PendingUnits:    t.PendingUnits,
+			TotalSlotMillis: t.TotalSlotMs,
+		})
+	}
+
+	for _, t := range q.ReferencedTables {
+		stats.ReferencedTables = append(stats.ReferencedTables, fmt.Sprintf("%s:%s.%s", t.ProjectId, t.DatasetId, t.TableId))
+	}
+
+	return stats
+}
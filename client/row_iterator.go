@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/api/iterator"
+)
+
+// RowIterator reads query results a page at a time from the Data sent by AsyncQuery, so that large results can be
+// processed without holding them all in memory
+type RowIterator struct {
+	dataChan chan Data
+	cancel   context.CancelFunc
+
+	headers []string
+	schema  Schema
+	stats   *QueryStats
+	done    bool
+	err     error
+}
+
+// Rows runs the query with AsyncQuery and returns an iterator over its result pages. Close must be called when the
+// iterator is not read to the end so that the query stops and its temp table, if any, is dropped
+func (c *Client) Rows(ctx context.Context, pageSize int, dataset, project, queryStr string, opts ...QueryOptions) *RowIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	dataChan := make(chan Data)
+	go c.AsyncQueryContext(ctx, pageSize, dataset, project, queryStr, dataChan, opts...)
+
+	it := NewRowIterator(dataChan)
+	it.cancel = cancel
+	return it
+}
+
+// NewRowIterator returns an iterator over the Data sent on dataChan, by AsyncQuery or any Querier such as
+// clienttest.Mock
+func NewRowIterator(dataChan chan Data) *RowIterator {
+	return &RowIterator{dataChan: dataChan}
+}
+
+// NextPage returns the next page of rows, or iterator.Done once every page has been returned
+func (it *RowIterator) NextPage() ([][]interface{}, error) {
+	for {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if it.done {
+			return nil, iterator.Done
+		}
+
+		d, ok := <-it.dataChan
+		if !ok {
+			it.finish()
+			continue
+		}
+		if d.Err != nil {
+			// the query sends no more data after an error, Close waits for the channel to be closed
+			it.err = d.Err
+			it.finish()
+			continue
+		}
+
+		if d.Headers != nil {
+			it.headers = d.Headers
+		}
+		if d.Schema != nil {
+			it.schema = d.Schema
+		}
+		if d.Stats != nil {
+			it.stats = d.Stats
+		}
+		if len(d.Rows) > 0 {
+			return d.Rows, nil
+		}
+	}
+}
+
+// Headers returns the column names, they are known once the first page or iterator.Done has been returned
+func (it *RowIterator) Headers() []string {
+	return it.headers
+}
+
+// Schema returns the schema of the results like Headers, it is nil when the Data sent carries no schema
+func (it *RowIterator) Schema() Schema {
+	return it.schema
+}
+
+// Stats returns the statistics of the query job once iterator.Done has been returned, nil if they could not be loaded
+func (it *RowIterator) Stats() *QueryStats {
+	return it.stats
+}
+
+// Close stops the query if it has not been read to the end, waiting for it to release its resources
+func (it *RowIterator) Close() error {
+	if it.done {
+		return nil
+	}
+
+	if it.cancel != nil {
+		it.cancel()
+	}
+	// the channel is closed once the query has stopped and dropped its temp table
+	for range it.dataChan {
+	}
+	it.finish()
+	return nil
+}
+
+func (it *RowIterator) finish() {
+	it.done = true
+	if it.cancel != nil {
+		it.cancel()
+	}
+}
@@ -0,0 +1,460 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/api/iterator"
+)
+
+// NestedMode selects how the writers output RECORD and REPEATED values
+type NestedMode int
+
+// The nested modes of WriteOptions
+const (
+	// NestedJSON writes RECORD and REPEATED values as JSON in a single column, NDJSON keeps them as nested objects
+	// and arrays
+	NestedJSON NestedMode = iota
+	// NestedFlatten expands non repeated RECORD columns to a column per nested field named by its dotted path, e.g.
+	// payload.user.id. REPEATED values are still written as JSON as their length varies between rows
+	NestedFlatten
+)
+
+const defaultMaxColumnWidth = 40
+
+// WriteOptions configures WriteCSV, WriteNDJSON, WriteJSON and WriteTable
+type WriteOptions struct {
+	Nested NestedMode
+
+	NoHeader  bool   // CSV only, do not write a header row
+	Delimiter rune   // CSV only, a comma by default
+	Null      string // how NULL values are written by WriteCSV and WriteTable, empty for CSV and NULL for tables by default
+
+	// MaxColumnWidth truncates the cells of WriteTable, 40 characters by default. The column widths are set by the
+	// header and the first page so that the table can be written as the pages arrive
+	MaxColumnWidth int
+}
+
+// column is an output column, the value of the top level field at index or, when flattened, of its nested field at
+// path
+type column struct {
+	name  string
+	index int
+	path  []string
+}
+
+// rowWriter converts the pages of a RowIterator to output rows, the columns are set up on the first page
+type rowWriter struct {
+	it      *RowIterator
+	opts    WriteOptions
+	columns []column
+}
+
+// next returns the next page of rows with their values in column order, calling init with the columns first
+func (rw *rowWriter) next(init func() error) ([][]interface{}, error) {
+	rows, err := rw.it.NextPage()
+	if err != nil && err != iterator.Done {
+		return nil, err
+	}
+
+	// the headers of empty results are known once the query is done
+	if rw.columns == nil {
+		rw.setColumns()
+		if ierr := init(); ierr != nil {
+			return nil, ierr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = rw.values(row)
+	}
+	return values, nil
+}
+
+func (rw *rowWriter) setColumns() {
+	schema := rw.it.Schema()
+	if schema == nil {
+		rw.columns = make([]column, len(rw.it.Headers()))
+		for i, h := range rw.it.Headers() {
+			rw.columns[i] = column{name: h, index: i}
+		}
+		return
+	}
+
+	rw.columns = []column{}
+	for i, f := range schema {
+		rw.columns = append(rw.columns, rw.fieldColumns(f, f.Name, i, nil)...)
+	}
+}
+
+// fieldColumns returns the columns of the field, a column per leaf field of RECORD fields when flattening
+func (rw *rowWriter) fieldColumns(f *Field, name string, index int, path []string) []column {
+	if rw.opts.Nested != NestedFlatten || normalizeFieldType(f.Type) != "RECORD" || fieldMode(f) == ModeRepeated {
+		return []column{{name: name, index: index, path: path}}
+	}
+
+	var columns []column
+	for _, nf := range f.Fields {
+		nested := append(append([]string{}, path...), nf.Name)
+		columns = append(columns, rw.fieldColumns(nf, name+"."+nf.Name, index, nested)...)
+	}
+	return columns
+}
+
+// values returns the values of the row in column order
+func (rw *rowWriter) values(row []interface{}) []interface{} {
+	schema := rw.it.Schema()
+	values := make([]interface{}, len(rw.columns))
+	for i, col := range rw.columns {
+		if col.index >= len(row) {
+			continue
+		}
+
+		v := row[col.index]
+		if col.index < len(schema) {
+			v = plainValue(schema[col.index], v)
+		}
+		for _, name := range col.path {
+			v = nestedValue(v, name)
+		}
+		values[i] = v
+	}
+	return values
+}
+
+func nestedValue(v interface{}, name string) interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m[name]
+	}
+	return nil
+}
+
+// plainValue unwraps the {"v": value} cells of REPEATED fields left by the decoding of the rows, recursing into RECORD
+// fields, so that values are plain strings, maps and slices
+func plainValue(f *Field, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	record := normalizeFieldType(f.Type) == "RECORD"
+	if fieldMode(f) == ModeRepeated {
+		switch vs := v.(type) {
+		case []map[string]interface{}:
+			values := make([]interface{}, len(vs))
+			for i, m := range vs {
+				values[i] = plainRecord(f.Fields, m)
+			}
+			return values
+		case []interface{}:
+			values := make([]interface{}, len(vs))
+			for i, cell := range vs {
+				if m, ok := cell.(map[string]interface{}); ok && !record {
+					cell = m["v"]
+				}
+				values[i] = cell
+			}
+			return values
+		}
+		return v
+	}
+
+	if m, ok := v.(map[string]interface{}); ok && record {
+		return plainRecord(f.Fields, m)
+	}
+	return v
+}
+
+func plainRecord(fields Schema, m map[string]interface{}) map[string]interface{} {
+	record := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		record[k] = v
+	}
+	for _, f := range fields {
+		if v, ok := record[f.Name]; ok {
+			record[f.Name] = plainValue(f, v)
+		}
+	}
+	return record
+}
+
+// cellString returns the text of a CSV or table cell, strings are written as is and other values as JSON
+func cellString(v interface{}, null string) string {
+	switch v := v.(type) {
+	case nil:
+		return null
+	case string:
+		return v
+	default:
+		b, err := marshalJSON(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// marshalJSON encodes v without escaping HTML characters
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// WriteCSV writes the rows of it to w as CSV, page by page as they are read. The header row holds the column names
+func WriteCSV(w io.Writer, it *RowIterator, opts WriteOptions) error {
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+
+	rw := &rowWriter{it: it, opts: opts}
+	for {
+		rows, err := rw.next(func() error {
+			if opts.NoHeader {
+				return nil
+			}
+			return cw.Write(columnNames(rw.columns))
+		})
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			cw.Flush()
+			return err
+		}
+
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, v := range row {
+				cells[i] = cellString(v, opts.Null)
+			}
+			if err = cw.Write(cells); err != nil {
+				return err
+			}
+		}
+		// flush every page so that the output streams
+		cw.Flush()
+		if err = cw.Error(); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteNDJSON writes the rows of it to w as newline delimited JSON objects with their keys in column order, page by
+// page as they are read
+func WriteNDJSON(w io.Writer, it *RowIterator, opts WriteOptions) error {
+	return writeObjects(w, it, opts, nil, []byte("\n"), nil)
+}
+
+// WriteJSON writes the rows of it to w as a JSON array of objects like WriteNDJSON, page by page as they are read
+func WriteJSON(w io.Writer, it *RowIterator, opts WriteOptions) error {
+	return writeObjects(w, it, opts, []byte("["), []byte(",\n"), []byte("]\n"))
+}
+
+// writeObjects writes the rows as JSON objects between open and end, separated by sep. When open is nil every object
+// is followed by sep instead
+func writeObjects(w io.Writer, it *RowIterator, opts WriteOptions, open, sep, end []byte) error {
+	bw := bufio.NewWriter(w)
+	rw := &rowWriter{it: it, opts: opts}
+
+	keys := make([][]byte, 0)
+	written := 0
+	for {
+		rows, err := rw.next(func() error {
+			for _, col := range rw.columns {
+				key, err := marshalJSON(col.name)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, append(key, ':'))
+			}
+			return nil
+		})
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+
+		for _, row := range rows {
+			if open != nil {
+				if written == 0 {
+					bw.Write(open)
+				} else {
+					bw.Write(sep)
+				}
+			}
+
+			bw.WriteByte('{')
+			for i, v := range row {
+				if i > 0 {
+					bw.WriteByte(',')
+				}
+				value, err := marshalJSON(v)
+				if err != nil {
+					return fmt.Errorf("error encoding %s: %v", rw.columns[i].name, err)
+				}
+				bw.Write(keys[i])
+				bw.Write(value)
+			}
+			bw.WriteByte('}')
+
+			if open == nil {
+				bw.Write(sep)
+			}
+			written++
+		}
+		if err = bw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if open != nil {
+		if written == 0 {
+			bw.Write(open)
+		}
+		bw.Write(end)
+	}
+	return bw.Flush()
+}
+
+// WriteTable writes the rows of it to w as a text table, page by page as they are read. The column widths are taken
+// from the header and the first page, longer cells are truncated
+func WriteTable(w io.Writer, it *RowIterator, opts WriteOptions) error {
+	if opts.Null == "" {
+		opts.Null = "NULL"
+	}
+	maxWidth := opts.MaxColumnWidth
+	if maxWidth <= 0 {
+		maxWidth = defaultMaxColumnWidth
+	}
+
+	bw := bufio.NewWriter(w)
+	rw := &rowWriter{it: it, opts: opts}
+
+	var widths []int
+	border := func() {
+		bw.WriteByte('+')
+		for _, width := range widths {
+			bw.WriteString(strings.Repeat("-", width+2))
+			bw.WriteByte('+')
+		}
+		bw.WriteByte('\n')
+	}
+	line := func(cells []string) {
+		bw.WriteByte('|')
+		for i, cell := range cells {
+			cell = truncate(cell, widths[i])
+			bw.WriteByte(' ')
+			bw.WriteString(cell)
+			bw.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+1))
+			bw.WriteByte('|')
+		}
+		bw.WriteByte('\n')
+	}
+
+	for {
+		rows, err := rw.next(func() error { return nil })
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+
+		cells := make([][]string, len(rows))
+		for i, row := range rows {
+			cells[i] = make([]string, len(row))
+			for j, v := range row {
+				cells[i][j] = cellLine(cellString(v, opts.Null))
+			}
+		}
+
+		if widths == nil {
+			widths = columnWidths(rw.columns, cells, maxWidth)
+			border()
+			line(columnNames(rw.columns))
+			border()
+		}
+		for _, row := range cells {
+			line(row)
+		}
+		if err = bw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if widths == nil {
+		// no rows, only the header is written
+		widths = columnWidths(rw.columns, nil, maxWidth)
+		border()
+		line(columnNames(rw.columns))
+	}
+	border()
+	return bw.Flush()
+}
+
+func columnNames(columns []column) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+	return names
+}
+
+func columnWidths(columns []column, rows [][]string, maxWidth int) []int {
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = utf8.RuneCountInString(col.name)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] > maxWidth {
+			widths[i] = maxWidth
+		}
+	}
+	return widths
+}
+
+// cellLine replaces the line breaks of a table cell so that every row is a single line
+func cellLine(s string) string {
+	return strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\t", " ").Replace(s)
+}
+
+// truncate shortens s to width characters, ending it with ~ when it is cut
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "~"
+}
@@ -0,0 +1,161 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+// pages returns an iterator over the pages of rows, sending err after them when set
+func pages(schema client.Schema, err error, rows ...[][]interface{}) *client.RowIterator {
+	headers := make([]string, len(schema))
+	for i, f := range schema {
+		headers[i] = f.Name
+	}
+
+	dataChan := make(chan client.Data, len(rows)+1)
+	for _, page := range rows {
+		dataChan <- client.Data{Headers: headers, Rows: page, Schema: schema}
+	}
+	if err != nil {
+		dataChan <- client.Data{Err: err}
+	} else {
+		close(dataChan)
+	}
+	return client.NewRowIterator(dataChan)
+}
+
+func TestWriters(t *testing.T) {
+	nested := client.Schema{
+		{Name: "id", Type: "INTEGER", Mode: client.ModeRequired},
+		{Name: "user", Type: "RECORD", Fields: client.Schema{
+			{Name: "name", Type: "STRING"},
+			{Name: "tags", Type: "STRING", Mode: client.ModeRepeated},
+		}},
+	}
+	row := []interface{}{"1", map[string]interface{}{
+		"name": "a<b>",
+		"tags": []interface{}{map[string]interface{}{"v": "x"}, map[string]interface{}{"v": "y"}},
+	}}
+	scalar := client.Schema{{Name: "a", Type: "STRING"}, {Name: "b", Type: "STRING"}}
+
+	tests := []struct {
+		name  string
+		write func(io.Writer, *client.RowIterator, client.WriteOptions) error
+		opts  client.WriteOptions
+		it    func() *client.RowIterator
+		want  string
+	}{
+		{
+			"csv", client.WriteCSV, client.WriteOptions{},
+			func() *client.RowIterator {
+				return pages(scalar, nil, [][]interface{}{{"x", nil}}, [][]interface{}{{"y,z", "w"}})
+			},
+			"a,b\nx,\n\"y,z\",w\n",
+		},
+		{
+			"csv options", client.WriteCSV, client.WriteOptions{NoHeader: true, Delimiter: ';', Null: `\N`},
+			func() *client.RowIterator { return pages(scalar, nil, [][]interface{}{{"x", nil}}) },
+			"x;\\N\n",
+		},
+		{
+			"csv nested", client.WriteCSV, client.WriteOptions{},
+			func() *client.RowIterator { return pages(nested, nil, [][]interface{}{row}) },
+			"id,user\n1,\"{\"\"name\"\":\"\"a<b>\"\",\"\"tags\"\":[\"\"x\"\",\"\"y\"\"]}\"\n",
+		},
+		{
+			"csv flattened", client.WriteCSV, client.WriteOptions{Nested: client.NestedFlatten},
+			func() *client.RowIterator { return pages(nested, nil, [][]interface{}{row}) },
+			"id,user.name,user.tags\n1,a<b>,\"[\"\"x\"\",\"\"y\"\"]\"\n",
+		},
+		{
+			"csv empty", client.WriteCSV, client.WriteOptions{},
+			func() *client.RowIterator { return pages(scalar, nil, [][]interface{}{}) },
+			"a,b\n",
+		},
+		{
+			"ndjson", client.WriteNDJSON, client.WriteOptions{},
+			func() *client.RowIterator { return pages(nested, nil, [][]interface{}{row, {"2", nil}}) },
+			`{"id":"1","user":{"name":"a<b>","tags":["x","y"]}}` + "\n" + `{"id":"2","user":null}` + "\n",
+		},
+		{
+			"json", client.WriteJSON, client.WriteOptions{},
+			func() *client.RowIterator {
+				return pages(scalar, nil, [][]interface{}{{"x", "y"}}, [][]interface{}{{"z", nil}})
+			},
+			`[{"a":"x","b":"y"},` + "\n" + `{"a":"z","b":null}]` + "\n",
+		},
+		{
+			"json empty", client.WriteJSON, client.WriteOptions{},
+			func() *client.RowIterator { return pages(scalar, nil) },
+			"[]\n",
+		},
+		{
+			"table", client.WriteTable, client.WriteOptions{MaxColumnWidth: 5},
+			func() *client.RowIterator {
+				return pages(scalar, nil, [][]interface{}{{"x", "abcdefgh"}, {"y\nz", nil}})
+			},
+			"+------+-------+\n" +
+				"| a    | b     |\n" +
+				"+------+-------+\n" +
+				"| x    | abcd~ |\n" +
+				"| y\\nz | NULL  |\n" +
+				"+------+-------+\n",
+		},
+		{
+			"table empty", client.WriteTable, client.WriteOptions{},
+			func() *client.RowIterator { return pages(scalar, nil, [][]interface{}{}) },
+			"+---+---+\n| a | b |\n+---+---+\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf, tt.it(), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWritersError(t *testing.T) {
+	queryErr := errors.New("query failed")
+	writers := map[string]func(io.Writer, *client.RowIterator, client.WriteOptions) error{
+		"csv":    client.WriteCSV,
+		"ndjson": client.WriteNDJSON,
+		"json":   client.WriteJSON,
+		"table":  client.WriteTable,
+	}
+
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			it := pages(client.Schema{{Name: "a", Type: "STRING"}}, queryErr, [][]interface{}{{"x"}})
+			if err := write(io.Discard, it, client.WriteOptions{}); err != queryErr {
+				t.Errorf("err = %v, want %v", err, queryErr)
+			}
+		})
+	}
+}
+
+func TestWriteCSVReplay(t *testing.T) {
+	bq := replayClient(t, "testdata/query_single_page.json")
+
+	it := bq.Rows(context.Background(), 100, "", "proj", "SELECT a FROM t")
+	defer it.Close()
+
+	var buf bytes.Buffer
+	if err := client.WriteCSV(&buf, it, client.WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\nx\ny\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,422 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Field modes understood by bigquery, an empty mode is treated as NULLABLE
+const (
+	ModeNullable = "NULLABLE"
+	ModeRequired = "REQUIRED"
+	ModeRepeated = "REPEATED"
+)
+
+// Schema is the ordered list of fields making up a table schema
+type Schema []*Field
+
+// Field describes a single column of a table, Fields is only populated for RECORD columns
+type Field struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Mode        string `json:"mode,omitempty"`
+	Description string `json:"description,omitempty"`
+	Fields      Schema `json:"fields,omitempty"`
+}
+
+// SchemaChangeKind identifies the kind of difference found between two schemas
+type SchemaChangeKind string
+
+// The kinds of differences reported when comparing schemas
+const (
+	FieldAdded              SchemaChangeKind = "added"
+	FieldRemoved            SchemaChangeKind = "removed"
+	FieldTypeChanged        SchemaChangeKind = "type changed"
+	FieldModeChanged        SchemaChangeKind = "mode changed"
+	FieldDescriptionChanged SchemaChangeKind = "description changed"
+)
+
+// SchemaChange describes a single difference between a current and a desired schema
+type SchemaChange struct {
+	Kind  SchemaChangeKind
+	Path  string // dotted path of the field, e.g. payload.user.id
+	Old   string // previous type, mode or description, empty for additions
+	New   string // desired type, mode or description, empty for removals
+	Field *Field // the desired field for additions, the current field otherwise
+}
+
+func (sc SchemaChange) String() string {
+	switch sc.Kind {
+	case FieldAdded:
+		return fmt.Sprintf("%s %s (%s %s)", sc.Path, sc.Kind, sc.Field.Type, fieldMode(sc.Field))
+	case FieldRemoved:
+		return fmt.Sprintf("%s %s", sc.Path, sc.Kind)
+	case FieldDescriptionChanged:
+		return fmt.Sprintf("%s %s from %q to %q", sc.Path, sc.Kind, sc.Old, sc.New)
+	default:
+		return fmt.Sprintf("%s %s from %s to %s", sc.Path, sc.Kind, sc.Old, sc.New)
+	}
+}
+
+// allowed reports whether bigquery can apply the change to a table without rewriting or losing data
+func (sc SchemaChange) allowed() bool {
+	switch sc.Kind {
+	case FieldAdded:
+		return fieldMode(sc.Field) == ModeNullable
+	case FieldModeChanged:
+		return sc.Old == ModeRequired && sc.New == ModeNullable
+	case FieldDescriptionChanged:
+		return true
+	}
+	return false
+}
+
+// breaksInserts reports whether rows built for the desired schema may be rejected by a table with the current schema
+func (sc SchemaChange) breaksInserts() bool {
+	switch sc.Kind {
+	case FieldRemoved:
+		// extra columns in the table only matter when they must be populated
+		return fieldMode(sc.Field) == ModeRequired
+	case FieldModeChanged:
+		return !(sc.Old == ModeNullable && sc.New == ModeRequired)
+	case FieldDescriptionChanged:
+		return false
+	}
+	return true
+}
+
+// IncompatibleSchemaError is returned when a desired schema contains changes that can not be applied to the
+// existing table, Changes lists every offending change
+type IncompatibleSchemaError struct {
+	Table   string
+	Changes []SchemaChange
+}
+
+func (e *IncompatibleSchemaError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, change := range e.Changes {
+		changes[i] = change.String()
+	}
+	return fmt.Sprintf("incompatible schema changes for %s: %s", e.Table, strings.Join(changes, "; "))
+}
+
+// SchemaDriftError is returned by CheckTable when the live table no longer accepts rows built for the expected
+// schema, Changes lists every offending difference
+type SchemaDriftError struct {
+	Table   string
+	Changes []SchemaChange
+}
+
+func (e *SchemaDriftError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, change := range e.Changes {
+		changes[i] = change.String()
+	}
+	return fmt.Sprintf("schema drift detected for %s: %s", e.Table, strings.Join(changes, "; "))
+}
+
+// SchemaPolicy controls how EvolveSchema applies the computed diff
+type SchemaPolicy struct {
+	PlanOnly bool      // print the diff without patching the table
+	Out      io.Writer // where the plan is printed, defaults to os.Stdout
+}
+
+// EvolveSchema fetches the current schema of the table, diffs it against desired and patches the table with the
+// allowed changes only: new NULLABLE columns, nested field additions, REQUIRED to NULLABLE relaxations and
+// description updates. If any other change is found nothing is applied and an *IncompatibleSchemaError is
+// returned. The full diff is returned in both cases.
+func (c *Client) EvolveSchema(ctx context.Context, projectID, datasetID, tableID string, desired Schema, policy SchemaPolicy) ([]SchemaChange, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", tableID)
+	table, err := service.Tables.Get(projectID, datasetID, tableID).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.evolveSchema(ctx, service, table, desired, policy)
+}
+
+// evolveSchema applies the allowed changes between the schema of the already loaded table and desired
+func (c *Client) evolveSchema(ctx context.Context, service *bigquery.Service, table *bigquery.Table, desired Schema, policy SchemaPolicy) ([]SchemaChange, error) {
+	tr := table.TableReference
+	tableName := fmt.Sprintf("%s:%s.%s", tr.ProjectId, tr.DatasetId, tr.TableId)
+
+	current := schemaFromBigQuery(table.Schema)
+	changes := DiffSchema(current, desired)
+
+	var incompatible []SchemaChange
+	for _, change := range changes {
+		if !change.allowed() {
+			incompatible = append(incompatible, change)
+		}
+	}
+
+	if policy.PlanOnly {
+		out := policy.Out
+		if out == nil {
+			out = os.Stdout
+		}
+		printSchemaPlan(out, tableName, changes)
+	}
+
+	if len(incompatible) > 0 {
+		return changes, &IncompatibleSchemaError{Table: tableName, Changes: incompatible}
+	}
+
+	if policy.PlanOnly || len(changes) == 0 {
+		return changes, nil
+	}
+
+	patch := &bigquery.Table{Schema: mergeSchema(current, desired).toBigQuery()}
+	ac := c.startCall(ctx, "tables.patch", "project", tr.ProjectId, "dataset", tr.DatasetId, "table", tr.TableId, "changes", len(changes))
+	_, err := service.Tables.Patch(tr.ProjectId, tr.DatasetId, tr.TableId, patch).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return changes, err
+	}
+
+	return changes, nil
+}
+
+// CheckTable compares the live schema of the table with the schema the caller inserts, desired, and returns a
+// *SchemaDriftError if rows built for desired could be rejected. It is intended for startup health checks, see
+// SchemaFromJSON and InferSchema for building desired from a schema file or a Go type
+func (c *Client) CheckTable(ctx context.Context, projectID, datasetID, tableID string, desired Schema) error {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", tableID)
+	table, err := service.Tables.Get(projectID, datasetID, tableID).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return err
+	}
+
+	var drift []SchemaChange
+	for _, change := range DiffSchema(schemaFromBigQuery(table.Schema), desired) {
+		if change.breaksInserts() {
+			drift = append(drift, change)
+		}
+	}
+
+	if len(drift) > 0 {
+		return &SchemaDriftError{Table: fmt.Sprintf("%s:%s.%s", projectID, datasetID, tableID), Changes: drift}
+	}
+
+	return nil
+}
+
+func printSchemaPlan(out io.Writer, tableName string, changes []SchemaChange) {
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "%s: schema is up to date\n", tableName)
+		return
+	}
+
+	fmt.Fprintf(out, "%s: %d schema change(s)\n", tableName, len(changes))
+	for _, change := range changes {
+		marker := "+"
+		if !change.allowed() {
+			marker = "!"
+		}
+		fmt.Fprintf(out, "  %s %s\n", marker, change)
+	}
+}
+
+// DiffSchema returns the changes required to turn the current schema into the desired one, nested fields are
+// reported with their dotted path. Descriptions are only compared when the desired field has one
+func DiffSchema(current, desired Schema) []SchemaChange {
+	return diffSchema(current, desired, "")
+}
+
+// diffSchema compares the fields of current and desired recursively, prefix is the dotted path of the parent record
+func diffSchema(current, desired Schema, prefix string) []SchemaChange {
+	var changes []SchemaChange
+
+	for _, cf := range current {
+		if desired.field(cf.Name) == nil {
+			changes = append(changes, SchemaChange{Kind: FieldRemoved, Path: prefix + cf.Name, Field: cf})
+		}
+	}
+
+	for _, df := range desired {
+		path := prefix + df.Name
+		cf := current.field(df.Name)
+		if cf == nil {
+			changes = append(changes, SchemaChange{Kind: FieldAdded, Path: path, New: df.Type, Field: df})
+			continue
+		}
+
+		if normalizeFieldType(cf.Type) != normalizeFieldType(df.Type) {
+			changes = append(changes, SchemaChange{Kind: FieldTypeChanged, Path: path, Old: cf.Type, New: df.Type, Field: cf})
+			continue
+		}
+
+		if fieldMode(cf) != fieldMode(df) {
+			changes = append(changes, SchemaChange{Kind: FieldModeChanged, Path: path, Old: fieldMode(cf), New: fieldMode(df), Field: cf})
+		}
+
+		if df.Description != "" && df.Description != cf.Description {
+			changes = append(changes, SchemaChange{Kind: FieldDescriptionChanged, Path: path, Old: cf.Description, New: df.Description, Field: cf})
+		}
+
+		if normalizeFieldType(cf.Type) == "RECORD" {
+			changes = append(changes, diffSchema(cf.Fields, df.Fields, path+".")...)
+		}
+	}
+
+	return changes
+}
+
+// mergeSchema returns a copy of current with the additions, mode relaxations and descriptions found in desired applied
+func mergeSchema(current, desired Schema) Schema {
+	merged := make(Schema, 0, len(current))
+	for _, cf := range current {
+		f := *cf
+		if df := desired.field(cf.Name); df != nil {
+			if fieldMode(cf) == ModeRequired && fieldMode(df) == ModeNullable {
+				f.Mode = ModeNullable
+			}
+			if df.Description != "" {
+				f.Description = df.Description
+			}
+			if normalizeFieldType(cf.Type) == "RECORD" {
+				f.Fields = mergeSchema(cf.Fields, df.Fields)
+			}
+		}
+		merged = append(merged, &f)
+	}
+
+	for _, df := range desired {
+		if current.field(df.Name) == nil {
+			merged = append(merged, df)
+		}
+	}
+
+	return merged
+}
+
+// field returns the field with the given name, bigquery column names are case insensitive
+func (s Schema) field(name string) *Field {
+	for _, f := range s {
+		if strings.EqualFold(f.Name, name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// fieldPath returns the field at the dotted path, descending into RECORD fields
+func (s Schema) fieldPath(path string) *Field {
+	names := strings.Split(path, ".")
+	var f *Field
+	for _, name := range names {
+		if f = s.field(name); f == nil {
+			return nil
+		}
+		s = f.Fields
+	}
+	return f
+}
+
+// names returns the names of the top level fields
+func (s Schema) names() []string {
+	names := make([]string, len(s))
+	for i, f := range s {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func (s Schema) toBigQuery() *bigquery.TableSchema {
+	return &bigquery.TableSchema{Fields: s.toBigQueryFields()}
+}
+
+func (s Schema) toBigQueryFields() []*bigquery.TableFieldSchema {
+	fields := make([]*bigquery.TableFieldSchema, len(s))
+	for i, f := range s {
+		fields[i] = &bigquery.TableFieldSchema{
+			Name:        f.Name,
+			Type:        f.Type,
+			Mode:        f.Mode,
+			Description: f.Description,
+			Fields:      f.Fields.toBigQueryFields(),
+		}
+	}
+	return fields
+}
+
+func schemaFromBigQuery(bqSchema *bigquery.TableSchema) Schema {
+	if bqSchema == nil {
+		return nil
+	}
+	return schemaFromBigQueryFields(bqSchema.Fields)
+}
+
+func schemaFromBigQueryFields(bqFields []*bigquery.TableFieldSchema) Schema {
+	var s Schema
+	for _, f := range bqFields {
+		s = append(s, &Field{
+			Name:        f.Name,
+			Type:        f.Type,
+			Mode:        f.Mode,
+			Description: f.Description,
+			Fields:      schemaFromBigQueryFields(f.Fields),
+		})
+	}
+	return s
+}
+
+// schemaFromFieldMap builds a schema of NULLABLE fields from a name to type map, sorted by name
+func schemaFromFieldMap(fields map[string]string) Schema {
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	s := make(Schema, len(names))
+	for i, name := range names {
+		s[i] = &Field{Name: name, Type: fields[name]}
+	}
+	return s
+}
+
+func fieldMode(f *Field) string {
+	if f.Mode == "" {
+		return ModeNullable
+	}
+	return strings.ToUpper(f.Mode)
+}
+
+// normalizeFieldType maps the standard sql type aliases onto the names returned by the tables API
+func normalizeFieldType(t string) string {
+	t = strings.ToUpper(t)
+	switch t {
+	case "INT64":
+		return "INTEGER"
+	case "FLOAT64":
+		return "FLOAT"
+	case "BOOL":
+		return "BOOLEAN"
+	case "STRUCT":
+		return "RECORD"
+	case "DECIMAL":
+		return "NUMERIC"
+	case "BIGDECIMAL":
+		return "BIGNUMERIC"
+	}
+	return t
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFromJSON reads a schema file in the format used by the bq command line tool, a JSON array of fields with
+// name, type, mode, description and nested fields keys
+func SchemaFromJSON(r io.Reader) (Schema, error) {
+	var s Schema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("error decoding schema: %v", err)
+	}
+	return s, nil
+}
+
+// InferSchema builds a schema from the exported fields of the struct v (or a pointer to it). Column names are
+// taken from a `bigquery:"name"` tag when present, fields tagged `bigquery:"-"` are skipped. Structs become
+// RECORD columns, slices REPEATED columns and every other column is NULLABLE.
+func InferSchema(v interface{}) (Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can not infer schema from %T, expected a struct", v)
+	}
+	return inferStructSchema(t, map[reflect.Type]bool{})
+}
+
+// inferStructSchema infers the schema of the struct type t, path holds the struct types being inferred above it so
+// that recursive types are reported instead of recursing forever
+func inferStructSchema(t reflect.Type, path map[reflect.Type]bool) (Schema, error) {
+	if path[t] {
+		return nil, fmt.Errorf("recursive type %s", t)
+	}
+	path[t] = true
+	defer delete(path, t)
+
+	var s Schema
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("bigquery"); ok {
+			tag = strings.Split(tag, ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		f, err := inferField(name, sf.Type, path)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, f)
+	}
+	return s, nil
+}
+
+func inferField(name string, t reflect.Type, path map[reflect.Type]bool) (*Field, error) {
+	f := &Field{Name: name, Mode: ModeNullable}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// []byte is a BYTES column rather than a repeated integer
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		f.Mode = ModeRepeated
+		t = t.Elem()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	switch {
+	case t == timeType:
+		f.Type = "TIMESTAMP"
+	case t.Kind() == reflect.Struct:
+		nested, err := inferStructSchema(t, path)
+		if err != nil {
+			return nil, err
+		}
+		f.Type = "RECORD"
+		f.Fields = nested
+	case t.Kind() == reflect.String:
+		f.Type = "STRING"
+	case t.Kind() == reflect.Bool:
+		f.Type = "BOOLEAN"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		f.Type = "INTEGER"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		f.Type = "FLOAT"
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		f.Type = "BYTES"
+	default:
+		return nil, fmt.Errorf("can not infer a column type for field %s of type %s", name, t)
+	}
+
+	return f, nil
+}
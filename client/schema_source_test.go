@@ -0,0 +1,86 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type inferAddress struct {
+	City string
+}
+
+type inferNode struct {
+	Name     string
+	Children []inferNode
+}
+
+type inferLinked struct {
+	Next *inferLinked
+}
+
+type inferIndirect struct {
+	Nested struct {
+		Back []*inferIndirect
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	type row struct {
+		ID       int64 `bigquery:"id"`
+		Name     *string
+		Tags     []string
+		Data     []byte
+		Score    float64
+		Active   bool
+		Created  time.Time
+		Home     inferAddress
+		Work     *inferAddress
+		Skipped  string `bigquery:"-"`
+		internal string
+	}
+
+	got, err := InferSchema(&row{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := Schema{{Name: "City", Type: "STRING", Mode: ModeNullable}}
+	want := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeNullable},
+		{Name: "Name", Type: "STRING", Mode: ModeNullable},
+		{Name: "Tags", Type: "STRING", Mode: ModeRepeated},
+		{Name: "Data", Type: "BYTES", Mode: ModeNullable},
+		{Name: "Score", Type: "FLOAT", Mode: ModeNullable},
+		{Name: "Active", Type: "BOOLEAN", Mode: ModeNullable},
+		{Name: "Created", Type: "TIMESTAMP", Mode: ModeNullable},
+		{Name: "Home", Type: "RECORD", Mode: ModeNullable, Fields: address},
+		{Name: "Work", Type: "RECORD", Mode: ModeNullable, Fields: address},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestInferSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"not a struct", 1, "expected a struct"},
+		{"unsupported type", struct{ M map[string]string }{}, "can not infer a column type for field M"},
+		{"recursive slice", inferNode{}, "recursive type client.inferNode"},
+		{"recursive pointer", &inferLinked{}, "recursive type client.inferLinked"},
+		{"recursive through a nested struct", inferIndirect{}, "recursive type client.inferIndirect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := InferSchema(tt.v)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
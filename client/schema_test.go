@@ -0,0 +1,124 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSchema(t *testing.T) {
+	current := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeRequired},
+		{Name: "name", Type: "STRING", Mode: ModeRequired, Description: "user name"},
+		{Name: "legacy", Type: "STRING"},
+		{Name: "payload", Type: "RECORD", Fields: Schema{
+			{Name: "kind", Type: "STRING"},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		desired Schema
+		want    []string
+	}{
+		{
+			"unchanged with aliases and case",
+			Schema{
+				{Name: "ID", Type: "INT64", Mode: ModeRequired},
+				{Name: "name", Type: "STRING", Mode: ModeRequired},
+				{Name: "legacy", Type: "STRING", Mode: ModeNullable},
+				{Name: "payload", Type: "STRUCT", Fields: Schema{{Name: "kind", Type: "STRING"}}},
+			},
+			nil,
+		},
+		{
+			"every kind of change",
+			Schema{
+				{Name: "id", Type: "STRING", Mode: ModeRequired},
+				{Name: "name", Type: "STRING", Mode: ModeNullable, Description: "display name"},
+				{Name: "payload", Type: "RECORD", Fields: Schema{
+					{Name: "kind", Type: "STRING"},
+					{Name: "size", Type: "INTEGER"},
+				}},
+				{Name: "created", Type: "TIMESTAMP", Mode: ModeRequired},
+			},
+			[]string{
+				"legacy removed",
+				"id type changed from INTEGER to STRING",
+				`name mode changed from REQUIRED to NULLABLE`,
+				`name description changed from "user name" to "display name"`,
+				"payload.size added (INTEGER NULLABLE)",
+				"created added (TIMESTAMP REQUIRED)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range DiffSchema(current, tt.desired) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaChangeAllowed(t *testing.T) {
+	tests := []struct {
+		change SchemaChange
+		want   bool
+	}{
+		{SchemaChange{Kind: FieldAdded, Field: &Field{Type: "STRING"}}, true},
+		{SchemaChange{Kind: FieldAdded, Field: &Field{Type: "STRING", Mode: ModeRequired}}, false},
+		{SchemaChange{Kind: FieldModeChanged, Old: ModeRequired, New: ModeNullable}, true},
+		{SchemaChange{Kind: FieldModeChanged, Old: ModeNullable, New: ModeRequired}, false},
+		{SchemaChange{Kind: FieldDescriptionChanged}, true},
+		{SchemaChange{Kind: FieldTypeChanged}, false},
+		{SchemaChange{Kind: FieldRemoved}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.allowed(); got != tt.want {
+			t.Errorf("%+v allowed = %v, want %v", tt.change, got, tt.want)
+		}
+	}
+}
+
+func TestMergeSchema(t *testing.T) {
+	current := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeRequired},
+		{Name: "name", Type: "STRING", Mode: ModeRequired},
+		{Name: "payload", Type: "RECORD", Fields: Schema{
+			{Name: "kind", Type: "STRING"},
+		}},
+		{Name: "legacy", Type: "STRING"},
+	}
+	desired := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeRequired, Description: "primary key"},
+		{Name: "name", Type: "STRING"},
+		{Name: "payload", Type: "RECORD", Fields: Schema{
+			{Name: "kind", Type: "STRING"},
+			{Name: "size", Type: "INTEGER"},
+		}},
+		{Name: "created", Type: "TIMESTAMP"},
+	}
+	want := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeRequired, Description: "primary key"},
+		{Name: "name", Type: "STRING", Mode: ModeNullable},
+		{Name: "payload", Type: "RECORD", Fields: Schema{
+			{Name: "kind", Type: "STRING"},
+			{Name: "size", Type: "INTEGER"},
+		}},
+		{Name: "legacy", Type: "STRING"},
+		{Name: "created", Type: "TIMESTAMP"},
+	}
+
+	if got := mergeSchema(current, desired); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if current[1].Mode != ModeRequired || len(current[2].Fields) != 1 {
+		t.Error("the current schema was modified")
+	}
+}
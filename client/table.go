@@ -1,15 +1,86 @@
 package client
 
-import bigquery "google.golang.org/api/bigquery/v2"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Time partitioning granularities accepted by TimePartitioning
+const (
+	PartitionHour  = "HOUR"
+	PartitionDay   = "DAY"
+	PartitionMonth = "MONTH"
+	PartitionYear  = "YEAR"
+)
+
+// maxClusteringFields is the number of clustering columns bigquery allows per table
+const maxClusteringFields = 4
+
+// TableOption is a configuration function applied to a table before it is created
+type TableOption func(*bigquery.Table) error
+
+// TimePartitioning is a table option that partitions the table by the given granularity (DAY, HOUR, MONTH or YEAR)
+// on the TIMESTAMP or DATE column field, or on ingestion time if field is empty. A zero expiration keeps
+// partitions forever
+//
+// An example use is:
+//
+// bqClient.InsertNewTable(projectID, datasetID, "events", fields, client.TimePartitioning(client.PartitionDay, "created_at", 90*24*time.Hour, true))
+func TimePartitioning(partitionType, field string, expiration time.Duration, requirePartitionFilter bool) TableOption {
+	return func(t *bigquery.Table) error {
+		partitionType = strings.ToUpper(partitionType)
+		switch partitionType {
+		case PartitionHour, PartitionDay, PartitionMonth, PartitionYear:
+		default:
+			return fmt.Errorf("invalid time partitioning type %q", partitionType)
+		}
+
+		t.TimePartitioning = &bigquery.TimePartitioning{
+			Type:         partitionType,
+			Field:        field,
+			ExpirationMs: int64(expiration / time.Millisecond),
+		}
+		t.RequirePartitionFilter = requirePartitionFilter
+		return nil
+	}
+}
 
-// InsertNewTable creates a new empty table for the given project and dataset with the field name/types defined in the fields map
-func (c *Client) InsertNewTable(projectID, datasetID, tableName string, fields map[string]string) error {
-	// If the table already exists, an error will be raised here.
-	service, err := c.connect()
-	if err != nil {
-		return err
+// RangePartitioning is a table option that partitions the table on the INTEGER column field, creating a partition
+// for every interval between start (inclusive) and end (exclusive)
+func RangePartitioning(field string, start, end, interval int64) TableOption {
+	return func(t *bigquery.Table) error {
+		if interval <= 0 || end <= start {
+			return fmt.Errorf("invalid range partitioning [%d, %d) with interval %d", start, end, interval)
+		}
+
+		t.RangePartitioning = &bigquery.RangePartitioning{
+			Field: field,
+			Range: &bigquery.RangePartitioningRange{Start: start, End: end, Interval: interval},
+		}
+		return nil
 	}
+}
+
+// Clustering is a table option that clusters the table by up to four columns, in order of precedence
+func Clustering(fields ...string) TableOption {
+	return func(t *bigquery.Table) error {
+		if len(fields) == 0 || len(fields) > maxClusteringFields {
+			return fmt.Errorf("clustering requires between 1 and %d fields, got %d", maxClusteringFields, len(fields))
+		}
 
+		t.Clustering = &bigquery.Clustering{Fields: fields}
+		return nil
+	}
+}
+
+// InsertNewTable creates a new empty table for the given project and dataset with the field name/types defined in the fields map,
+// options can be used to partition and cluster the table
+func (c *Client) InsertNewTable(projectID, datasetID, tableName string, fields map[string]string, options ...TableOption) error {
 	// build the table schema
 	schema := &bigquery.TableSchema{}
 	for k, v := range fields {
@@ -27,7 +98,31 @@ func (c *Client) InsertNewTable(projectID, datasetID, tableName string, fields m
 
 	table.TableReference = tr
 
-	_, err = service.Tables.Insert(projectID, datasetID, table).Do()
+	return c.insertTable(context.Background(), table, options...)
+}
+
+// insertTable applies the options to the table and creates it, if the table already exists an error will be raised
+func (c *Client) insertTable(ctx context.Context, table *bigquery.Table, options ...TableOption) error {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range options {
+		err = option(table)
+		if err != nil {
+			return err
+		}
+	}
+
+	tr := table.TableReference
+	if table.TimePartitioning != nil && table.RangePartitioning != nil {
+		return fmt.Errorf("table %s can not use both time and range partitioning", tr.TableId)
+	}
+
+	ac := c.startCall(ctx, "tables.insert", "project", tr.ProjectId, "dataset", tr.DatasetId, "table", tr.TableId)
+	_, err = service.Tables.Insert(tr.ProjectId, tr.DatasetId, table).Context(ac.ctx).Do()
+	ac.end(err)
 	if err != nil {
 		return err
 	}
@@ -35,59 +130,82 @@ func (c *Client) InsertNewTable(projectID, datasetID, tableName string, fields m
 	return nil
 }
 
-func (c *Client) InsertNewTableIfDoesNotExist(projectID, datasetID, tableID string, fields map[string]string) error {
+func (c *Client) InsertNewTableIfDoesNotExist(projectID, datasetID, tableID string, fields map[string]string, options ...TableOption) error {
 	// This will not return an error if the table already exists
 	exists, err := c.tableDoesExist(projectID, datasetID, tableID)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return c.InsertNewTable(projectID, datasetID, tableID, fields)
+		return c.InsertNewTable(projectID, datasetID, tableID, fields, options...)
 	}
 	return nil
 }
 
-// PatchTableSchema sends a patch request to bigquery to modify the table with only the fields provided
+// PatchTableSchema adds the provided fields to the table schema as NULLABLE columns, columns not listed in fields are
+// left untouched. Changing the type of an existing column returns an *IncompatibleSchemaError, see EvolveSchema
 func (c *Client) PatchTableSchema(projectID, datasetID, tableID string, fields map[string]string) error {
-	service, err := c.connect()
+	ctx := context.Background()
+	service, err := c.connect(ctx)
 	if err != nil {
 		return err
 	}
 
-	// build the table schema
-	schema := &bigquery.TableSchema{}
-	for k, v := range fields {
-		schema.Fields = append(schema.Fields, &bigquery.TableFieldSchema{Name: k, Type: v})
-	}
-
-	table := &bigquery.Table{}
-	table.Schema = schema
-
-	tr := &bigquery.TableReference{}
-	tr.DatasetId = datasetID
-	tr.ProjectId = projectID
-	tr.TableId = tableID
-
-	table.TableReference = tr
-
-	_, err = service.Tables.Patch(projectID, datasetID, tableID, table).Do()
+	ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", tableID)
+	table, err := service.Tables.Get(projectID, datasetID, tableID).Context(ac.ctx).Do()
+	ac.end(err)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// start from the current schema so that only the provided fields are added or changed
+	desired := schemaFromBigQuery(table.Schema)
+	for _, f := range schemaFromFieldMap(fields) {
+		if existing := desired.field(f.Name); existing != nil {
+			existing.Type = f.Type
+		} else {
+			desired = append(desired, f)
+		}
+	}
+
+	_, err = c.evolveSchema(ctx, service, table, desired, SchemaPolicy{})
+	return err
 }
 
 func (c *Client) tableDoesExist(projectID, datasetID, tableID string) (bool, error) {
 	// return err only if connection fails
-	service, err := c.connect()
+	ctx := context.Background()
+	service, err := c.connect(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	_, err = service.Tables.Get(projectID, datasetID, tableID).Do()
+	ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", tableID)
+	_, err = service.Tables.Get(projectID, datasetID, tableID).Context(ac.ctx).Do()
+	ac.end(nil, "exists", err == nil)
 	if err != nil {
 		return false, nil
 	}
 	return true, nil
 }
+
+// DeleteTable deletes the given table, if ignoreNotFound is true deleting a table that does not exist is not an error
+func (c *Client) DeleteTable(ctx context.Context, projectID, datasetID, tableID string, ignoreNotFound bool) error {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	ac := c.startCall(ctx, "tables.delete", "project", projectID, "dataset", datasetID, "table", tableID)
+	err = service.Tables.Delete(projectID, datasetID, tableID).Context(ac.ctx).Do()
+	if ignoreNotFound && isHTTPStatus(err, http.StatusNotFound) {
+		ac.end(nil, "not_found", true)
+		return nil
+	}
+	ac.end(err)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/iterator"
+)
+
+// TableFilter restricts the tables returned by ListTables, empty fields match every table
+type TableFilter struct {
+	Prefix string            // table ID prefix, e.g. a date-sharded table name without its date suffix
+	Type   string            // one of the TableType constants
+	Labels map[string]string // every label must be present, an empty value matches any value of the key
+}
+
+func (f TableFilter) match(md *TableMetadata) bool {
+	if f.Prefix != "" && !strings.HasPrefix(md.TableID, f.Prefix) {
+		return false
+	}
+	if f.Type != "" && md.Type != f.Type {
+		return false
+	}
+	for k, v := range f.Labels {
+		lv, ok := md.Labels[k]
+		if !ok || (v != "" && lv != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// TableIterator iterates over the tables of a dataset, loading them a page at a time
+type TableIterator struct {
+	ctx       context.Context
+	client    *Client
+	projectID string
+	datasetID string
+	filter    TableFilter
+
+	buf       []*TableMetadata
+	pageToken string
+	done      bool
+	err       error
+}
+
+// ListTables returns an iterator over the tables of the dataset matching filter. The metadata returned is the subset
+// provided by the list API: the schema, sizes and streaming buffer stats are not populated, use GetTable for those
+func (c *Client) ListTables(ctx context.Context, projectID, datasetID string, filter TableFilter) *TableIterator {
+	return &TableIterator{
+		ctx:       ctx,
+		client:    c,
+		projectID: projectID,
+		datasetID: datasetID,
+		filter:    filter,
+	}
+}
+
+// Next returns the next table, or iterator.Done once every table has been returned
+func (it *TableIterator) Next() (*TableMetadata, error) {
+	for len(it.buf) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if it.done {
+			return nil, iterator.Done
+		}
+		it.err = it.fetch()
+	}
+
+	md := it.buf[0]
+	it.buf = it.buf[1:]
+	return md, nil
+}
+
+// fetch loads the next page of tables, keeping the ones matching the filter
+func (it *TableIterator) fetch() error {
+	service, err := it.client.connect(it.ctx)
+	if err != nil {
+		return err
+	}
+
+	call := service.Tables.List(it.projectID, it.datasetID)
+	if len(it.pageToken) > 0 {
+		call.PageToken(it.pageToken)
+	}
+
+	ac := it.client.startCall(it.ctx, "tables.list", "project", it.projectID, "dataset", it.datasetID)
+	list, err := call.Context(ac.ctx).Do()
+	if err != nil {
+		ac.end(err)
+		return err
+	}
+	ac.end(nil, "tables", len(list.Tables))
+
+	for _, t := range list.Tables {
+		md := tableMetadataFromBigQuery(&bigquery.Table{
+			TableReference:    t.TableReference,
+			Type:              t.Type,
+			FriendlyName:      t.FriendlyName,
+			Labels:            t.Labels,
+			CreationTime:      t.CreationTime,
+			ExpirationTime:    t.ExpirationTime,
+			TimePartitioning:  t.TimePartitioning,
+			RangePartitioning: t.RangePartitioning,
+			Clustering:        t.Clustering,
+		})
+		if it.filter.match(md) {
+			it.buf = append(it.buf, md)
+		}
+	}
+
+	it.pageToken = list.NextPageToken
+	it.done = it.pageToken == ""
+	return nil
+}
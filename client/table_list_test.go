@@ -0,0 +1,45 @@
+package client_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"google.golang.org/api/iterator"
+)
+
+func TestListTables(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/tables_list.json")
+
+	it := bq.ListTables(context.Background(), "proj", "ds", client.TableFilter{
+		Prefix: "events_",
+		Type:   client.TableTypeTable,
+		Labels: map[string]string{"env": "prod"},
+	})
+
+	var tables []string
+	for {
+		md, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, md.TableID)
+	}
+
+	if want := []string{"events_20240101", "events_20240103"}; !reflect.DeepEqual(tables, want) {
+		t.Errorf("tables = %v, want %v", tables, want)
+	}
+	if len(calls.urls) != 2 {
+		t.Fatalf("%d tables.list calls, want 2", len(calls.urls))
+	}
+	if token := calls.urls[0].Query().Get("pageToken"); token != "" {
+		t.Errorf("first page token = %q, want none", token)
+	}
+	if token := calls.urls[1].Query().Get("pageToken"); token != "p2" {
+		t.Errorf("second page token = %q, want p2", token)
+	}
+}
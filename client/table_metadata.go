@@ -0,0 +1,304 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Table types reported in TableMetadata.Type
+const (
+	TableTypeTable            = "TABLE"
+	TableTypeView             = "VIEW"
+	TableTypeMaterializedView = "MATERIALIZED_VIEW"
+	TableTypeExternal         = "EXTERNAL"
+	TableTypeSnapshot         = "SNAPSHOT"
+)
+
+// TableMetadata is the metadata of a table as returned by GetTable
+type TableMetadata struct {
+	ProjectID    string
+	DatasetID    string
+	TableID      string
+	Type         string // one of the TableType constants
+	FriendlyName string
+	Description  string
+	Labels       map[string]string
+	Location     string
+	Etag         string // pass to UpdateTable to detect concurrent modifications
+
+	Schema   Schema
+	NumRows  uint64 // excludes rows still in the streaming buffer
+	NumBytes int64
+
+	CreationTime     time.Time
+	LastModifiedTime time.Time
+	ExpirationTime   time.Time // zero if the table never expires
+
+	TimePartitioning       *TimePartitionSpec  // nil if the table is not time partitioned
+	RangePartitioning      *RangePartitionSpec // nil if the table is not range partitioned
+	RequirePartitionFilter bool
+	ClusteringFields       []string
+
+	StreamingBuffer *StreamingBufferStats // nil if the table has no rows in the streaming buffer
+}
+
+// TimePartitionSpec describes the time partitioning of a table, an empty Field means ingestion time partitioning
+type TimePartitionSpec struct {
+	Type       string
+	Field      string
+	Expiration time.Duration
+}
+
+// RangePartitionSpec describes the integer range partitioning of a table
+type RangePartitionSpec struct {
+	Field    string
+	Start    int64
+	End      int64
+	Interval int64
+}
+
+// StreamingBufferStats are the estimated size of the rows streamed into a table that are not yet in managed storage
+type StreamingBufferStats struct {
+	EstimatedRows   uint64
+	EstimatedBytes  uint64
+	OldestEntryTime time.Time
+}
+
+// GetTable loads the metadata of the given table
+func (c *Client) GetTable(ctx context.Context, projectID, datasetID, tableID string) (*TableMetadata, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", tableID)
+	table, err := service.Tables.Get(projectID, datasetID, tableID).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return tableMetadataFromBigQuery(table), nil
+}
+
+// TableUpdate lists the metadata changes made by UpdateTable, nil or empty fields are left untouched
+type TableUpdate struct {
+	Description  *string
+	FriendlyName *string
+	Labels       map[string]string // labels to add or overwrite
+	DeleteLabels []string          // label keys to remove
+
+	ExpirationTime      *time.Time     // a zero time removes the expiration
+	PartitionExpiration *time.Duration // time partitioned tables only, zero removes the expiration
+	ClusteringFields    []string       // replaces the clustering columns, a non-nil empty slice removes clustering
+
+	ColumnDescriptions map[string]string // keyed by dotted column path, e.g. payload.user.id
+}
+
+// UpdateTable applies the metadata changes in update to the table and returns the updated metadata. If etag is not
+// empty, typically TableMetadata.Etag from GetTable, the update only succeeds if the table has not been modified since
+// and a *ConflictError is returned otherwise
+func (c *Client) UpdateTable(ctx context.Context, projectID, datasetID, tableID string, update TableUpdate, etag string) (*TableMetadata, error) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := fmt.Sprintf("%s:%s.%s", projectID, datasetID, tableID)
+	patch := &bigquery.Table{}
+
+	if update.Description != nil {
+		patch.Description = *update.Description
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+	}
+
+	if update.FriendlyName != nil {
+		patch.FriendlyName = *update.FriendlyName
+		patch.ForceSendFields = append(patch.ForceSendFields, "FriendlyName")
+	}
+
+	if len(update.Labels) > 0 {
+		patch.Labels = update.Labels
+	}
+	for _, k := range update.DeleteLabels {
+		patch.NullFields = append(patch.NullFields, "Labels."+k)
+	}
+	if len(update.DeleteLabels) > 0 && len(update.Labels) == 0 {
+		// null label values are only sent along with a labels map
+		patch.ForceSendFields = append(patch.ForceSendFields, "Labels")
+	}
+
+	if update.ExpirationTime != nil {
+		if update.ExpirationTime.IsZero() {
+			patch.NullFields = append(patch.NullFields, "ExpirationTime")
+		} else {
+			patch.ExpirationTime = update.ExpirationTime.UnixNano() / int64(time.Millisecond)
+		}
+	}
+
+	if update.ClusteringFields != nil {
+		if len(update.ClusteringFields) == 0 {
+			patch.NullFields = append(patch.NullFields, "Clustering")
+		} else if err = Clustering(update.ClusteringFields...)(patch); err != nil {
+			return nil, err
+		}
+	}
+
+	// partition expiration and column descriptions are nested in structures that are replaced as a whole,
+	// so they are applied on top of the current table
+	if update.PartitionExpiration != nil || len(update.ColumnDescriptions) > 0 {
+		ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", tableID)
+		current, err := service.Tables.Get(projectID, datasetID, tableID).Context(ac.ctx).Do()
+		ac.end(err)
+		if err != nil {
+			return nil, err
+		}
+
+		if etag == "" {
+			// protect the read-modify-write of the current table
+			etag = current.Etag
+		} else if etag != current.Etag {
+			return nil, &ConflictError{Resource: tableName, Etag: etag, Err: fmt.Errorf("current etag is %s", current.Etag)}
+		}
+
+		if update.PartitionExpiration != nil {
+			if current.TimePartitioning == nil {
+				return nil, fmt.Errorf("table %s is not time partitioned", tableName)
+			}
+			tp := *current.TimePartitioning
+			tp.ExpirationMs = int64(*update.PartitionExpiration / time.Millisecond)
+			if tp.ExpirationMs == 0 {
+				tp.NullFields = append(tp.NullFields, "ExpirationMs")
+			}
+			patch.TimePartitioning = &tp
+		}
+
+		if len(update.ColumnDescriptions) > 0 {
+			schema := schemaFromBigQuery(current.Schema)
+			for path, description := range update.ColumnDescriptions {
+				f := schema.fieldPath(path)
+				if f == nil {
+					return nil, fmt.Errorf("column %s not found in table %s", path, tableName)
+				}
+				f.Description = description
+			}
+			patch.Schema = schema.toBigQuery()
+		}
+	}
+
+	call := service.Tables.Patch(projectID, datasetID, tableID, patch)
+	if etag != "" {
+		call.Header().Set("If-Match", etag)
+	}
+
+	ac := c.startCall(ctx, "tables.patch", "project", projectID, "dataset", datasetID, "table", tableID)
+	table, err := call.Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, conflictError(err, tableName, etag)
+	}
+
+	return tableMetadataFromBigQuery(table), nil
+}
+
+func tableMetadataFromBigQuery(t *bigquery.Table) *TableMetadata {
+	md := &TableMetadata{
+		Type:                   t.Type,
+		FriendlyName:           t.FriendlyName,
+		Description:            t.Description,
+		Labels:                 t.Labels,
+		Location:               t.Location,
+		Etag:                   t.Etag,
+		Schema:                 schemaFromBigQuery(t.Schema),
+		NumRows:                t.NumRows,
+		NumBytes:               t.NumBytes,
+		CreationTime:           msToTime(t.CreationTime),
+		LastModifiedTime:       msToTime(int64(t.LastModifiedTime)),
+		ExpirationTime:         msToTime(t.ExpirationTime),
+		RequirePartitionFilter: t.RequirePartitionFilter,
+	}
+
+	if t.TableReference != nil {
+		md.ProjectID = t.TableReference.ProjectId
+		md.DatasetID = t.TableReference.DatasetId
+		md.TableID = t.TableReference.TableId
+	}
+
+	if tp := t.TimePartitioning; tp != nil {
+		md.TimePartitioning = &TimePartitionSpec{
+			Type:       tp.Type,
+			Field:      tp.Field,
+			Expiration: time.Duration(tp.ExpirationMs) * time.Millisecond,
+		}
+		// older tables only carry the flag on the partitioning itself
+		md.RequirePartitionFilter = md.RequirePartitionFilter || tp.RequirePartitionFilter
+	}
+
+	if rp := t.RangePartitioning; rp != nil && rp.Range != nil {
+		md.RangePartitioning = &RangePartitionSpec{
+			Field:    rp.Field,
+			Start:    rp.Range.Start,
+			End:      rp.Range.End,
+			Interval: rp.Range.Interval,
+		}
+	}
+
+	if t.Clustering != nil {
+		md.ClusteringFields = t.Clustering.Fields
+	}
+
+	if sb := t.StreamingBuffer; sb != nil {
+		md.StreamingBuffer = &StreamingBufferStats{
+			EstimatedRows:   sb.EstimatedRows,
+			EstimatedBytes:  sb.EstimatedBytes,
+			OldestEntryTime: msToTime(int64(sb.OldestEntryTime)),
+		}
+	}
+
+	return md
+}
+
+// msToTime converts a bigquery millisecond timestamp, returning the zero time for unset values
+func msToTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// splitTableName splits a table name in the legacy [project:dataset.table] or standard project.dataset.table form,
+// using defaultProject and defaultDataset when the name does not include them
+func splitTableName(defaultProject, defaultDataset, name string) (projectID, datasetID, tableID string, ok bool) {
+	name = strings.Trim(name, "[]`")
+	projectID = defaultProject
+
+	if i := strings.Index(name, ":"); i >= 0 {
+		projectID, name = name[:i], name[i+1:]
+	}
+
+	parts := strings.Split(name, ".")
+	switch len(parts) {
+	case 1:
+		if len(defaultDataset) > 0 && len(parts[0]) > 0 {
+			return projectID, defaultDataset, parts[0], true
+		}
+	case 2:
+		return projectID, parts[0], parts[1], true
+	case 3:
+		return parts[0], parts[1], parts[2], true
+	}
+	return "", "", "", false
+}
+
+// splitDatasetName splits a dataset name in the project:dataset or project.dataset form, using defaultProject when the
+// name does not include one
+func splitDatasetName(defaultProject, name string) (projectID, datasetID string) {
+	if i := strings.IndexAny(name, ":."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return defaultProject, name
+}
@@ -0,0 +1,109 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func TestUpdateTableConflict(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/table_update_conflict.json")
+
+	description := "events"
+	_, err := bq.UpdateTable(context.Background(), "proj", "ds", "events", client.TableUpdate{
+		Description:  &description,
+		DeleteLabels: []string{"tmp"},
+	}, "e1")
+
+	var conflict *client.ConflictError
+	if !errors.As(err, &conflict) || conflict.Etag != "e1" {
+		t.Fatalf("err = %v, want a conflict on etag e1", err)
+	}
+	if etag := calls.headers["tables.patch"][0].Get("If-Match"); etag != "e1" {
+		t.Errorf("If-Match = %q, want e1", etag)
+	}
+	if body, want := calls.bodies["tables.patch"][0], `{"description":"events","labels":{"tmp":null}}`+"\n"; body != want {
+		t.Errorf("patch = %s, want %s", body, want)
+	}
+}
+
+func TestUpdateTableStaleEtag(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/table_update_stale.json")
+
+	_, err := bq.UpdateTable(context.Background(), "proj", "ds", "events", client.TableUpdate{
+		ColumnDescriptions: map[string]string{"id": "event id"},
+	}, "e1")
+
+	var conflict *client.ConflictError
+	if !errors.As(err, &conflict) || conflict.Etag != "e1" {
+		t.Fatalf("err = %v, want a conflict on etag e1", err)
+	}
+	if n := calls.calls["tables.patch"]; n != 0 {
+		t.Errorf("%d patches sent for a stale etag", n)
+	}
+}
+
+func TestUpdateTableColumnDescriptions(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/table_update_columns.json")
+
+	md, err := bq.UpdateTable(context.Background(), "proj", "ds", "events", client.TableUpdate{
+		ColumnDescriptions: map[string]string{"payload.user.id": "user id"},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Etag != "e3" {
+		t.Errorf("etag = %s, want e3", md.Etag)
+	}
+
+	// the read-modify-write of the schema is guarded by the etag that was read
+	if etag := calls.headers["tables.patch"][0].Get("If-Match"); etag != "e2" {
+		t.Errorf("If-Match = %q, want e2", etag)
+	}
+
+	var patch bigquery.Table
+	if err = json.Unmarshal([]byte(calls.bodies["tables.patch"][0]), &patch); err != nil {
+		t.Fatal(err)
+	}
+	want := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		{Name: "id", Type: "INTEGER", Mode: "REQUIRED"},
+		{Name: "payload", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{
+			{Name: "user", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{
+				{Name: "id", Type: "STRING", Description: "user id"},
+				{Name: "name", Type: "STRING", Description: "display name"},
+			}},
+		}},
+	}}
+	if !reflect.DeepEqual(patch.Schema, want) {
+		got, _ := json.Marshal(patch.Schema)
+		t.Errorf("patched schema = %s", got)
+	}
+}
+
+func TestCount(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/table_count.json")
+
+	tests := []struct {
+		table string
+		want  int64
+	}{
+		{"events", 42},
+		{"other.events", 7},
+		{"[p2:ds.events]", 3},
+	}
+
+	for _, tt := range tests {
+		if got := bq.Count("ds", "proj", tt.table); got != tt.want {
+			t.Errorf("Count(%s) = %d, want %d", tt.table, got, tt.want)
+		}
+	}
+	// the counts come from the table metadata
+	if n := calls.calls["jobs.query"]; n != 0 {
+		t.Errorf("%d queries run", n)
+	}
+}
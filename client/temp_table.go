@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// createTempTable creates an empty, uniquely named table with the given schema, which may be nil, in the scratch dataset
+// or in dataset if none is configured, to be used by a single query or load job. The table expires on its own in case
+// it is never dropped
+func (c *Client) createTempTable(ctx context.Context, project, dataset string, schema *bigquery.TableSchema) (*bigquery.TableReference, error) {
+	if len(c.scratchDataset) > 0 {
+		project, dataset = splitDatasetName(project, c.scratchDataset)
+	}
+
+	tableID, err := c.tempTableID()
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &bigquery.TableReference{ProjectId: project, DatasetId: dataset, TableId: tableID}
+	table := &bigquery.Table{
+		TableReference: tr,
+		Schema:         schema,
+		ExpirationTime: time.Now().Add(c.tempTableExpiration).UnixNano() / int64(time.Millisecond),
+	}
+
+	err = c.insertTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log().Debug("created temp table", "project", tr.ProjectId, "dataset", tr.DatasetId, "table", tr.TableId)
+	return tr, nil
+}
+
+// dropTempTable deletes a table created by createTempTable, failures are only logged as the table expires anyway
+func (c *Client) dropTempTable(ctx context.Context, tr *bigquery.TableReference) {
+	service, err := c.connect(ctx)
+	if err != nil {
+		c.log().Warn("could not drop temp table", "table", tr.TableId, "err", err)
+		return
+	}
+
+	ac := c.startCall(ctx, "tables.delete", "project", tr.ProjectId, "dataset", tr.DatasetId, "table", tr.TableId)
+	err = service.Tables.Delete(tr.ProjectId, tr.DatasetId, tr.TableId).Context(ac.ctx).Do()
+	if isHTTPStatus(err, http.StatusNotFound) {
+		err = nil
+	}
+	ac.end(err)
+}
+
+// tempTableID generates a table name from the configured prefix and a unique suffix
+func (c *Client) tempTableID() (string, error) {
+	prefix := c.tempTableName
+	if len(prefix) == 0 {
+		prefix = defaultTempTablePrefix
+	}
+
+	suffix, err := uniqueSuffix()
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + "_" + suffix, nil
+}
+
+// uniqueSuffix returns the current time followed by random hex digits, usable in table names and job IDs
+func uniqueSuffix() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s_%s", time.Now().UTC().Format("20060102150405"), hex.EncodeToString(b)), nil
+}
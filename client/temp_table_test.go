@@ -0,0 +1,217 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/iterator"
+)
+
+// stubTransport answers the API requests with handle and keeps the method and path of every request
+type stubTransport struct {
+	mu       sync.Mutex
+	requests []string
+	handle   func(req *http.Request, body []byte) (*http.Response, error)
+}
+
+func (st *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	st.mu.Lock()
+	st.requests = append(st.requests, req.Method+" "+req.URL.Path)
+	st.mu.Unlock()
+	return st.handle(req, body)
+}
+
+func (st *stubTransport) sent(request string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, r := range st.requests {
+		if r == request {
+			return true
+		}
+	}
+	return false
+}
+
+func stubResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestCreateTempTable(t *testing.T) {
+	var created bigquery.Table
+	st := &stubTransport{handle: func(req *http.Request, body []byte) (*http.Response, error) {
+		if err := json.Unmarshal(body, &created); err != nil {
+			return nil, err
+		}
+		return stubResponse(http.StatusOK, string(body)), nil
+	}}
+
+	tests := []struct {
+		name    string
+		options []func(*Client) error
+		path    string
+		dataset string
+		prefix  string
+		expires time.Duration
+	}{
+		{"query dataset", nil, "POST /bigquery/v2/projects/proj/datasets/ds/tables", "ds", "bq_tmp_", defaultTempTableExpiration},
+		{
+			"scratch dataset",
+			[]func(*Client) error{ScratchDataset("other.scratch", 10*time.Minute), AllowLargeResults(true, "tmp", false)},
+			"POST /bigquery/v2/projects/other/datasets/scratch/tables", "scratch", "tmp_", 10 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New("", append(tt.options, WithHTTPClient(&http.Client{Transport: st}))...)
+			schema := Schema{{Name: "a", Type: "STRING"}}.toBigQuery()
+
+			start := time.Now()
+			tr, err := c.createTempTable(context.Background(), "proj", "ds", schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !st.sent(tt.path) {
+				t.Errorf("requests = %v, want %s", st.requests, tt.path)
+			}
+			if tr.DatasetId != tt.dataset || !strings.HasPrefix(tr.TableId, tt.prefix) || created.TableReference.TableId != tr.TableId {
+				t.Errorf("unexpected temp table %+v", tr)
+			}
+			if len(created.Schema.Fields) != 1 || created.Schema.Fields[0].Name != "a" {
+				t.Errorf("unexpected schema %+v", created.Schema)
+			}
+
+			expires := time.Unix(0, created.ExpirationTime*int64(time.Millisecond))
+			if expires.Before(start.Add(tt.expires-time.Second)) || expires.After(time.Now().Add(tt.expires)) {
+				t.Errorf("expiration = %s, want %s from now", expires, tt.expires)
+			}
+		})
+	}
+}
+
+func TestTempTableID(t *testing.T) {
+	c := New("")
+	pattern := regexp.MustCompile(`^bq_tmp_\d{14}_[0-9a-f]{16}$`)
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id, err := c.tempTableID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pattern.MatchString(id) {
+			t.Fatalf("unexpected temp table ID %s", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate temp table ID %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestDropTempTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		logged bool
+	}{
+		{"dropped", http.StatusNoContent, false},
+		{"already gone", http.StatusNotFound, false},
+		{"failed", http.StatusForbidden, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &stubTransport{handle: func(req *http.Request, body []byte) (*http.Response, error) {
+				if tt.status == http.StatusNoContent {
+					return stubResponse(tt.status, ""), nil
+				}
+				return stubResponse(tt.status, `{"error": {"code": 0, "message": "error"}}`), nil
+			}}
+			var logs bytes.Buffer
+			c := New("", WithHTTPClient(&http.Client{Transport: st}), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+			c.dropTempTable(context.Background(), &bigquery.TableReference{ProjectId: "proj", DatasetId: "ds", TableId: "bq_tmp_1"})
+
+			if !st.sent("DELETE /bigquery/v2/projects/proj/datasets/ds/tables/bq_tmp_1") {
+				t.Errorf("requests = %v, want the temp table deleted", st.requests)
+			}
+			if logged := strings.Contains(logs.String(), "level=ERROR"); logged != tt.logged {
+				t.Errorf("error logged = %v, want %v: %s", logged, tt.logged, logs.String())
+			}
+		})
+	}
+}
+
+func TestRowIteratorCloseDropsTempTable(t *testing.T) {
+	var dropped atomic.Bool
+	st := &stubTransport{}
+	st.handle = func(req *http.Request, body []byte) (*http.Response, error) {
+		switch {
+		case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/tables"):
+			return stubResponse(http.StatusOK, string(body)), nil
+		case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/jobs"):
+			return stubResponse(http.StatusOK, `{"jobReference": {"projectId": "proj", "jobId": "job_1"}}`), nil
+		case req.Method == http.MethodGet && req.URL.Query().Get("pageToken") == "":
+			return stubResponse(http.StatusOK, `{"jobReference": {"projectId": "proj", "jobId": "job_1"}, "jobComplete": true,
+				"schema": {"fields": [{"name": "a", "type": "STRING"}]}, "rows": [{"f": [{"v": "x"}]}], "totalRows": "2", "pageToken": "p2"}`), nil
+		case req.Method == http.MethodGet:
+			// the second page never arrives, the query only stops when the iterator is closed
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		case req.Method == http.MethodDelete:
+			// a slow drop, that Close still waits for
+			time.Sleep(50 * time.Millisecond)
+			dropped.Store(true)
+			return stubResponse(http.StatusNoContent, ""), nil
+		}
+		return stubResponse(http.StatusNotFound, `{"error": {"code": 404, "message": "unexpected request"}}`), nil
+	}
+
+	c := New("", WithHTTPClient(&http.Client{Transport: st}), AllowLargeResults(true, "", false))
+	it := c.Rows(context.Background(), 1, "ds", "proj", "SELECT a FROM t")
+
+	rows, err := it.NextPage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("rows = %v, want the first page", rows)
+	}
+
+	if err = it.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !dropped.Load() {
+		t.Errorf("requests = %v, want the temp table dropped before Close returns", st.requests)
+	}
+	if _, err = it.NextPage(); err != iterator.Done {
+		t.Errorf("err = %v after Close, want iterator.Done", err)
+	}
+}
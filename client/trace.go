@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by the client
+const tracerName = "github.com/dailyburn/bigquery/client"
+
+// WithTracerProvider is a configuration function that sets the OpenTelemetry tracer provider used to trace the API
+// calls of the client, the global provider is used by default
+//
+// An example use is:
+//
+// client.New(pemPath, client.WithTracerProvider(sdktrace.NewTracerProvider(...)))
+func WithTracerProvider(tp trace.TracerProvider) func(*Client) error {
+	return func(c *Client) error {
+		c.tracerProvider = tp
+		return nil
+	}
+}
+
+// tracer returns the tracer of the configured provider or of the global one
+func (c *Client) tracer() trace.Tracer {
+	if c.tracerProvider != nil {
+		return c.tracerProvider.Tracer(tracerName)
+	}
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// startSpan starts a span named bigquery.<name> as a child of any span in ctx, args are key/value pairs set as
+// attributes of the span
+func (c *Client) startSpan(ctx context.Context, name string, args ...interface{}) (context.Context, trace.Span) {
+	return c.tracer().Start(ctx, "bigquery."+name, trace.WithAttributes(spanAttributes(args)...))
+}
+
+// endSpan records err, if any, and ends the span, args are key/value pairs describing the outcome
+func endSpan(span trace.Span, err error, args ...interface{}) {
+	span.SetAttributes(spanAttributes(args)...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// apiCall tracks a single bigquery API call so that its outcome and latency are logged and traced along with its
+// context. Pass ctx to the API call so that the HTTP request is made within the span and interceptors see its name
+type apiCall struct {
+	c     *Client
+	ctx   context.Context
+	span  trace.Span
+	name  string
+	start time.Time
+	args  []interface{}
+}
+
+// startCall starts tracking the API call name, args are key/value pairs identifying the resources involved
+func (c *Client) startCall(ctx context.Context, name string, args ...interface{}) *apiCall {
+	ctx, span := c.startSpan(withCallName(ctx, name), name, args...)
+	return &apiCall{c: c, ctx: ctx, span: span, name: name, start: time.Now(), args: args}
+}
+
+// end logs the outcome of the call and ends its span, args are key/value pairs describing its result
+func (a *apiCall) end(err error, args ...interface{}) {
+	endSpan(a.span, err, args...)
+
+	logArgs := append([]interface{}{"call", a.name}, a.args...)
+	logArgs = append(logArgs, args...)
+	logArgs = append(logArgs, "latency", time.Since(a.start))
+
+	if err != nil {
+		a.c.log().Error("bigquery call failed", append(logArgs, "err", err)...)
+		return
+	}
+	a.c.log().Debug("bigquery call completed", logArgs...)
+}
+
+// spanAttributes converts key/value pairs to span attributes prefixed with bigquery.
+func spanAttributes(args []interface{}) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key := fmt.Sprintf("bigquery.%v", args[i])
+		switch v := args[i+1].(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case int:
+			attrs = append(attrs, attribute.Int(key, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(key, v))
+		case uint64:
+			attrs = append(attrs, attribute.Int64(key, int64(v)))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, v))
+		case time.Duration:
+			attrs = append(attrs, attribute.Int64(key+"_ms", v.Milliseconds()))
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+	return attrs
+}
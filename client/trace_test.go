@@ -0,0 +1,111 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+	"github.com/dailyburn/bigquery/client/clienttest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// tracedClient returns a client replaying golden whose spans are kept by the returned recorder
+func tracedClient(t *testing.T, golden string) (*client.Client, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	rec, err := clienttest.NewRecorder(golden, clienttest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	return client.New("", client.WithHTTPClient(rec.Client()), client.WithTracerProvider(tp)), spans
+}
+
+// spansByName returns the ended spans by name
+func spansByName(spans *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans.Ended() {
+		byName[s.Name()] = s
+	}
+	return byName
+}
+
+// spanAttribute returns the value of the attribute key of the span
+func spanAttribute(s sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestQuerySpans(t *testing.T) {
+	bq, spans := tracedClient(t, "testdata/query_single_page.json")
+
+	if _, _, err := bq.Query("ds", "proj", "SELECT a FROM t"); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := spansByName(spans)
+	query, call := byName["bigquery.query"], byName["bigquery.jobs.query"]
+	if query == nil || call == nil {
+		t.Fatalf("spans = %v, want bigquery.query and bigquery.jobs.query", byName)
+	}
+
+	if call.Parent().SpanID() != query.SpanContext().SpanID() {
+		t.Error("the jobs.query span is not a child of the query span")
+	}
+	if call.Status().Code == codes.Error || query.Status().Code == codes.Error {
+		t.Errorf("statuses = %v and %v, want no error", call.Status(), query.Status())
+	}
+
+	tests := []struct {
+		span sdktrace.ReadOnlySpan
+		key  string
+		want attribute.Value
+	}{
+		{query, "bigquery.project", attribute.StringValue("proj")},
+		{query, "bigquery.dataset", attribute.StringValue("ds")},
+		{query, "bigquery.job_id", attribute.StringValue("job_1")},
+		{query, "bigquery.rows", attribute.IntValue(2)},
+		{call, "bigquery.job_id", attribute.StringValue("job_1")},
+		{call, "bigquery.bytes_processed", attribute.Int64Value(1024)},
+	}
+	for _, tt := range tests {
+		if got := spanAttribute(tt.span, tt.key); got != tt.want {
+			t.Errorf("%s %s = %v, want %v", tt.span.Name(), tt.key, got.Emit(), tt.want.Emit())
+		}
+	}
+}
+
+func TestQuerySpansError(t *testing.T) {
+	bq, spans := tracedClient(t, "testdata/query_page_error.json")
+
+	if _, _, err := bq.Query("ds", "proj", "SELECT a FROM t"); err == nil {
+		t.Fatal("the page error was not returned")
+	}
+
+	byName := spansByName(spans)
+	for _, name := range []string{"bigquery.query", "bigquery.jobs.getQueryResults"} {
+		s := byName[name]
+		if s == nil {
+			t.Fatalf("spans = %v, want %s", byName, name)
+		}
+		if s.Status().Code != codes.Error {
+			t.Errorf("%s status = %v, want an error", name, s.Status())
+		}
+		if len(s.Events()) == 0 || s.Events()[0].Name != "exception" {
+			t.Errorf("%s events = %v, want the error recorded", name, s.Events())
+		}
+	}
+}
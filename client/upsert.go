@@ -0,0 +1,270 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// Upsert inserts the rows into the target table, or updates the existing rows with the same keyColumns values. The rows
+// are loaded into a temporary staging table with the schema of the target relaxed to NULLABLE, merged into the target
+// with a generated MERGE statement and the staging table is dropped. Only the columns present in a row are written for
+// that row, so rows with different column sets leave the columns they omit untouched, and when several rows share the
+// same key the last one wins
+func (c *Client) Upsert(ctx context.Context, projectID, datasetID, tableID string, keyColumns []string, rows []map[string]interface{}) (*ExecResult, error) {
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("upsert requires at least one key column")
+	}
+	if len(rows) == 0 {
+		return &ExecResult{}, nil
+	}
+
+	service, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", tableID)
+	target, err := service.Tables.Get(projectID, datasetID, tableID).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = dedupeRows(rows, keyColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := upsertColumns(schemaFromBigQuery(target.Schema), keyColumns, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// rows may omit REQUIRED columns that are only updated, so the staging table accepts NULL everywhere, and columns
+	// some rows omit get a flag telling a NULL value apart from a missing one
+	flags := presenceFlags(keyColumns, columns, rows)
+	relaxed := nullableSchema(schemaFromBigQuery(target.Schema))
+	for _, col := range columns {
+		if flag, ok := flags[col]; ok {
+			relaxed = append(relaxed, &Field{Name: flag, Type: "BOOLEAN", Mode: ModeRequired})
+		}
+	}
+	stagingSchema := relaxed.toBigQuery()
+
+	staging, err := c.createTempTable(ctx, projectID, datasetID, stagingSchema)
+	if err != nil {
+		return nil, err
+	}
+	defer c.dropTempTable(context.WithoutCancel(ctx), staging)
+
+	err = c.loadRows(ctx, staging, stagingSchema, stagingRows(rows, flags))
+	if err != nil {
+		return nil, err
+	}
+
+	merge := mergeStatement(target.TableReference, staging, keyColumns, columns, flags)
+	c.log().Debug("running merge", "project", projectID, "dataset", datasetID, "table", tableID, "rows", len(rows), "query", merge)
+
+	return c.Exec(ctx, datasetID, projectID, merge)
+}
+
+// loadRows loads the rows into the table with a newline delimited JSON load job, replacing its content
+func (c *Client) loadRows(ctx context.Context, tr *bigquery.TableReference, schema *bigquery.TableSchema, rows []map[string]interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("error encoding row: %v", err)
+		}
+	}
+
+	_, err := c.Load(ctx, tr.ProjectId, tr.DatasetId, tr.TableId, LoadConfig{
+		Source:            &buf,
+		Format:            FormatNDJSON,
+		Schema:            schemaFromBigQuery(schema),
+		WriteDisposition:  WriteTruncate,
+		CreateDisposition: CreateNever,
+	})
+	return err
+}
+
+// nullableSchema returns a copy of the schema with the REQUIRED fields made NULLABLE, down to nested fields
+func nullableSchema(schema Schema) Schema {
+	relaxed := make(Schema, len(schema))
+	for i, f := range schema {
+		nf := *f
+		if fieldMode(f) != ModeRepeated {
+			nf.Mode = ModeNullable
+		}
+		if len(f.Fields) > 0 {
+			nf.Fields = nullableSchema(f.Fields)
+		}
+		relaxed[i] = &nf
+	}
+	return relaxed
+}
+
+// dedupeRows keeps the last row for every key, preserving the order in which keys first appear
+func dedupeRows(rows []map[string]interface{}, keyColumns []string) ([]map[string]interface{}, error) {
+	index := make(map[string]int, len(rows))
+	deduped := make([]map[string]interface{}, 0, len(rows))
+
+	for _, row := range rows {
+		keyValues := make([]interface{}, len(keyColumns))
+		for i, k := range keyColumns {
+			v, ok := row[k]
+			if !ok || v == nil {
+				return nil, fmt.Errorf("row is missing a value for key column %s", k)
+			}
+			keyValues[i] = v
+		}
+
+		key, err := json.Marshal(keyValues)
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := index[string(key)]; ok {
+			deduped[i] = row
+			continue
+		}
+		index[string(key)] = len(deduped)
+		deduped = append(deduped, row)
+	}
+
+	return deduped, nil
+}
+
+// upsertColumns returns the target columns written by the rows in schema order, checking that every key and row
+// column exists and that keys can be compared
+func upsertColumns(schema Schema, keyColumns []string, rows []map[string]interface{}) ([]string, error) {
+	for _, k := range keyColumns {
+		f := schema.field(k)
+		if f == nil {
+			return nil, fmt.Errorf("key column %s not found in table", k)
+		}
+		if fieldMode(f) == ModeRepeated || normalizeFieldType(f.Type) == "RECORD" {
+			return nil, fmt.Errorf("key column %s must be a scalar column", k)
+		}
+	}
+
+	present := make(map[string]bool)
+	for _, row := range rows {
+		for k := range row {
+			if schema.field(k) == nil {
+				return nil, fmt.Errorf("column %s not found in table", k)
+			}
+			present[strings.ToLower(k)] = true
+		}
+	}
+
+	var columns []string
+	for _, f := range schema {
+		if present[strings.ToLower(f.Name)] {
+			columns = append(columns, f.Name)
+		}
+	}
+
+	return columns, nil
+}
+
+// presenceFlags returns the staging flag column of every non key column that some rows omit
+func presenceFlags(keyColumns, columns []string, rows []map[string]interface{}) map[string]string {
+	isKey := make(map[string]bool, len(keyColumns))
+	for _, k := range keyColumns {
+		isKey[strings.ToLower(k)] = true
+	}
+
+	flags := make(map[string]string)
+	for i, col := range columns {
+		if isKey[strings.ToLower(col)] {
+			continue
+		}
+		for _, row := range rows {
+			if !hasColumn(row, col) {
+				flags[col] = fmt.Sprintf("_upsert_has_%d", i)
+				break
+			}
+		}
+	}
+
+	return flags
+}
+
+// stagingRows returns copies of the rows with the presence flags set
+func stagingRows(rows []map[string]interface{}, flags map[string]string) []map[string]interface{} {
+	if len(flags) == 0 {
+		return rows
+	}
+
+	staged := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		s := make(map[string]interface{}, len(row)+len(flags))
+		for k, v := range row {
+			s[k] = v
+		}
+		for col, flag := range flags {
+			s[flag] = hasColumn(row, col)
+		}
+		staged[i] = s
+	}
+
+	return staged
+}
+
+func hasColumn(row map[string]interface{}, col string) bool {
+	for k := range row {
+		if strings.EqualFold(k, col) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeStatement builds a standard SQL MERGE of the staging table into the target on the key columns, updating and
+// inserting the given columns. Columns with a presence flag keep the target value for rows that omitted them. Both
+// tables share the same column types, so values need no casting
+func mergeStatement(target, staging *bigquery.TableReference, keyColumns, columns []string, flags map[string]string) string {
+	isKey := make(map[string]bool, len(keyColumns))
+	on := make([]string, len(keyColumns))
+	for i, k := range keyColumns {
+		isKey[strings.ToLower(k)] = true
+		on[i] = fmt.Sprintf("T.%s = S.%s", quoteIdentifier(k), quoteIdentifier(k))
+	}
+
+	var set, insertColumns, insertValues []string
+	for _, col := range columns {
+		insertColumns = append(insertColumns, quoteIdentifier(col))
+		insertValues = append(insertValues, "S."+quoteIdentifier(col))
+		if isKey[strings.ToLower(col)] {
+			continue
+		}
+		if flag, ok := flags[col]; ok {
+			set = append(set, fmt.Sprintf("%s = IF(S.%s, S.%s, T.%s)", quoteIdentifier(col), quoteIdentifier(flag), quoteIdentifier(col), quoteIdentifier(col)))
+		} else {
+			set = append(set, fmt.Sprintf("%s = S.%s", quoteIdentifier(col), quoteIdentifier(col)))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "MERGE %s T\nUSING %s S\nON %s\n", quoteTable(target), quoteTable(staging), strings.Join(on, " AND "))
+	if len(set) > 0 {
+		fmt.Fprintf(&b, "WHEN MATCHED THEN UPDATE SET %s\n", strings.Join(set, ", "))
+	}
+	fmt.Fprintf(&b, "WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", strings.Join(insertColumns, ", "), strings.Join(insertValues, ", "))
+
+	return b.String()
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "\\`", -1) + "`"
+}
+
+func quoteTable(tr *bigquery.TableReference) string {
+	return quoteIdentifier(tr.ProjectId + "." + tr.DatasetId + "." + tr.TableId)
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func TestMergeStatement(t *testing.T) {
+	target := &bigquery.TableReference{ProjectId: "proj", DatasetId: "ds", TableId: "users"}
+	staging := &bigquery.TableReference{ProjectId: "proj", DatasetId: "ds", TableId: "tmp_1"}
+
+	tests := []struct {
+		name       string
+		keyColumns []string
+		columns    []string
+		flags      map[string]string
+		want       string
+	}{
+		{
+			"single key",
+			[]string{"id"},
+			[]string{"id", "name", "email"},
+			nil,
+			"MERGE `proj.ds.users` T\nUSING `proj.ds.tmp_1` S\nON T.`id` = S.`id`\n" +
+				"WHEN MATCHED THEN UPDATE SET `name` = S.`name`, `email` = S.`email`\n" +
+				"WHEN NOT MATCHED THEN INSERT (`id`, `name`, `email`) VALUES (S.`id`, S.`name`, S.`email`)",
+		},
+		{
+			"composite key",
+			[]string{"org", "ID"},
+			[]string{"org", "id", "name"},
+			nil,
+			"MERGE `proj.ds.users` T\nUSING `proj.ds.tmp_1` S\nON T.`org` = S.`org` AND T.`ID` = S.`ID`\n" +
+				"WHEN MATCHED THEN UPDATE SET `name` = S.`name`\n" +
+				"WHEN NOT MATCHED THEN INSERT (`org`, `id`, `name`) VALUES (S.`org`, S.`id`, S.`name`)",
+		},
+		{
+			"keys only",
+			[]string{"id"},
+			[]string{"id"},
+			nil,
+			"MERGE `proj.ds.users` T\nUSING `proj.ds.tmp_1` S\nON T.`id` = S.`id`\n" +
+				"WHEN NOT MATCHED THEN INSERT (`id`) VALUES (S.`id`)",
+		},
+		{
+			"quoted identifier",
+			[]string{"id"},
+			[]string{"id", "a`b"},
+			nil,
+			"MERGE `proj.ds.users` T\nUSING `proj.ds.tmp_1` S\nON T.`id` = S.`id`\n" +
+				"WHEN MATCHED THEN UPDATE SET `a\\`b` = S.`a\\`b`\n" +
+				"WHEN NOT MATCHED THEN INSERT (`id`, `a\\`b`) VALUES (S.`id`, S.`a\\`b`)",
+		},
+		{
+			"partial columns",
+			[]string{"id"},
+			[]string{"id", "name", "email"},
+			map[string]string{"email": "_upsert_has_2"},
+			"MERGE `proj.ds.users` T\nUSING `proj.ds.tmp_1` S\nON T.`id` = S.`id`\n" +
+				"WHEN MATCHED THEN UPDATE SET `name` = S.`name`, `email` = IF(S.`_upsert_has_2`, S.`email`, T.`email`)\n" +
+				"WHEN NOT MATCHED THEN INSERT (`id`, `name`, `email`) VALUES (S.`id`, S.`name`, S.`email`)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeStatement(target, staging, tt.keyColumns, tt.columns, tt.flags); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullableSchema(t *testing.T) {
+	schema := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeRequired},
+		{Name: "tags", Type: "STRING", Mode: ModeRepeated},
+		{Name: "user", Type: "RECORD", Mode: ModeRequired, Fields: Schema{
+			{Name: "name", Type: "STRING", Mode: ModeRequired},
+			{Name: "note", Type: "STRING"},
+		}},
+	}
+	want := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeNullable},
+		{Name: "tags", Type: "STRING", Mode: ModeRepeated},
+		{Name: "user", Type: "RECORD", Mode: ModeNullable, Fields: Schema{
+			{Name: "name", Type: "STRING", Mode: ModeNullable},
+			{Name: "note", Type: "STRING", Mode: ModeNullable},
+		}},
+	}
+
+	if got := nullableSchema(schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if schema[0].Mode != ModeRequired || schema[2].Fields[0].Mode != ModeRequired {
+		t.Error("the original schema was modified")
+	}
+}
+
+func TestUpsertDifferentColumnSets(t *testing.T) {
+	schema := Schema{
+		{Name: "id", Type: "INTEGER", Mode: ModeRequired},
+		{Name: "name", Type: "STRING"},
+		{Name: "email", Type: "STRING"},
+		{Name: "age", Type: "INTEGER"},
+	}
+	keyColumns := []string{"id"}
+	rows := []map[string]interface{}{
+		{"id": 1, "name": "a", "email": "a@example.com"},
+		{"id": 2, "Name": "b", "age": 30},
+	}
+
+	columns, err := upsertColumns(schema, keyColumns, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "name", "email", "age"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+
+	flags := presenceFlags(keyColumns, columns, rows)
+	if want := map[string]string{"email": "_upsert_has_2", "age": "_upsert_has_3"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("flags = %v, want %v", flags, want)
+	}
+
+	want := []map[string]interface{}{
+		{"id": 1, "name": "a", "email": "a@example.com", "_upsert_has_2": true, "_upsert_has_3": false},
+		{"id": 2, "Name": "b", "age": 30, "_upsert_has_2": false, "_upsert_has_3": true},
+	}
+	if got := stagingRows(rows, flags); !reflect.DeepEqual(got, want) {
+		t.Errorf("staging rows = %v, want %v", got, want)
+	}
+	if _, ok := rows[0]["_upsert_has_2"]; ok {
+		t.Error("the original rows were modified")
+	}
+
+	if flags := presenceFlags(keyColumns, []string{"id", "name"}, rows[:1]); len(flags) != 0 {
+		t.Errorf("flags = %v for rows with the same columns", flags)
+	}
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// ViewOptions configures a view created with CreateView
+type ViewOptions struct {
+	UseLegacySQL bool // views use standard SQL unless set
+	Description  string
+	Labels       map[string]string
+
+	// UserDefinedFunctionResources are inline javascript UDFs or gs:// URIs of files defining them, legacy SQL views
+	// only as standard SQL views define their functions with CREATE TEMP FUNCTION in the query
+	UserDefinedFunctionResources []string
+
+	// AuthorizedDatasets lists the datasets, in project:dataset form or just the dataset name for the project of
+	// the view, that the view is authorized to read on behalf of its users
+	AuthorizedDatasets []string
+}
+
+// MaterializedViewOptions configures a materialized view created with CreateMaterializedView
+type MaterializedViewOptions struct {
+	DisableRefresh  bool          // materialized views are refreshed automatically unless set
+	RefreshInterval time.Duration // zero keeps the bigquery default of 30 minutes
+	Description     string
+	Labels          map[string]string
+}
+
+// CreateView creates a view defined by query in the given project and dataset, and authorizes it on the datasets
+// listed in opts.AuthorizedDatasets
+func (c *Client) CreateView(ctx context.Context, projectID, datasetID, viewID, query string, opts ViewOptions) error {
+	if len(opts.UserDefinedFunctionResources) > 0 && !opts.UseLegacySQL {
+		return fmt.Errorf("view %s can only use user defined function resources with legacy SQL", viewID)
+	}
+
+	view := &bigquery.ViewDefinition{Query: query, UseLegacySql: opts.UseLegacySQL}
+	if !opts.UseLegacySQL {
+		// the API defaults to legacy SQL when the flag is omitted
+		view.ForceSendFields = []string{"UseLegacySql"}
+	}
+
+	for _, udf := range opts.UserDefinedFunctionResources {
+		if strings.HasPrefix(udf, "gs://") {
+			view.UserDefinedFunctionResources = append(view.UserDefinedFunctionResources, &bigquery.UserDefinedFunctionResource{ResourceUri: udf})
+		} else {
+			view.UserDefinedFunctionResources = append(view.UserDefinedFunctionResources, &bigquery.UserDefinedFunctionResource{InlineCode: udf})
+		}
+	}
+
+	table := &bigquery.Table{
+		TableReference: &bigquery.TableReference{ProjectId: projectID, DatasetId: datasetID, TableId: viewID},
+		View:           view,
+		Description:    opts.Description,
+		Labels:         opts.Labels,
+	}
+
+	err := c.insertTable(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range opts.AuthorizedDatasets {
+		sourceProjectID, sourceDatasetID := splitDatasetName(projectID, name)
+		_, err = c.GrantDatasetAccess(ctx, sourceProjectID, sourceDatasetID, AuthorizedViewAccess(projectID, datasetID, viewID))
+		if err != nil {
+			return fmt.Errorf("view %s created but could not be authorized on %s: %v", viewID, name, err)
+		}
+	}
+
+	return nil
+}
+
+// CreateMaterializedView creates a materialized view defined by query in the given project and dataset, options can
+// be used to partition and cluster it
+func (c *Client) CreateMaterializedView(ctx context.Context, projectID, datasetID, viewID, query string, opts MaterializedViewOptions, options ...TableOption) error {
+	mv := &bigquery.MaterializedViewDefinition{
+		Query:             query,
+		EnableRefresh:     !opts.DisableRefresh,
+		RefreshIntervalMs: int64(opts.RefreshInterval / time.Millisecond),
+		ForceSendFields:   []string{"EnableRefresh"},
+	}
+
+	table := &bigquery.Table{
+		TableReference:   &bigquery.TableReference{ProjectId: projectID, DatasetId: datasetID, TableId: viewID},
+		MaterializedView: mv,
+		Description:      opts.Description,
+		Labels:           opts.Labels,
+	}
+
+	return c.insertTable(ctx, table, options...)
+}
+
+// UpdateViewQuery replaces the query of an existing view, keeping its other settings. The query of a materialized
+// view can not be changed, it must be dropped and created again
+func (c *Client) UpdateViewQuery(ctx context.Context, projectID, datasetID, viewID, query string) error {
+	service, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	ac := c.startCall(ctx, "tables.get", "project", projectID, "dataset", datasetID, "table", viewID)
+	table, err := service.Tables.Get(projectID, datasetID, viewID).Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return err
+	}
+
+	if table.View == nil {
+		return fmt.Errorf("%s:%s.%s is a %s, not a view", projectID, datasetID, viewID, table.Type)
+	}
+
+	view := *table.View
+	view.Query = query
+	view.ForceSendFields = []string{"UseLegacySql"}
+
+	call := service.Tables.Patch(projectID, datasetID, viewID, &bigquery.Table{View: &view})
+	call.Header().Set("If-Match", table.Etag)
+
+	ac = c.startCall(ctx, "tables.patch", "project", projectID, "dataset", datasetID, "table", viewID)
+	_, err = call.Context(ac.ctx).Do()
+	ac.end(err)
+	if err != nil {
+		return conflictError(err, fmt.Sprintf("%s:%s.%s", projectID, datasetID, viewID), table.Etag)
+	}
+
+	return nil
+}
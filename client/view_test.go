@@ -0,0 +1,76 @@
+package client_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+func TestCreateView(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/view_create.json")
+
+	err := bq.CreateView(context.Background(), "proj", "views", "v", "SELECT a FROM source.t", client.ViewOptions{
+		AuthorizedDatasets: []string{"source"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// standard SQL must be requested explicitly
+	if body := calls.bodies["tables.insert"][0]; !strings.Contains(body, `"view":{"query":"SELECT a FROM source.t","useLegacySql":false}`) {
+		t.Errorf("unexpected view %s", body)
+	}
+	if body := calls.bodies["datasets.patch"][0]; !strings.Contains(body, `{"view":{"datasetId":"views","projectId":"proj","tableId":"v"}}`) {
+		t.Errorf("view not authorized: %s", body)
+	}
+}
+
+func TestCreateViewUDF(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/view_create_legacy.json")
+
+	err := bq.CreateView(context.Background(), "proj", "views", "v", "SELECT f(a) FROM [proj:source.t]", client.ViewOptions{
+		UseLegacySQL:                 true,
+		UserDefinedFunctionResources: []string{"gs://bucket/f.js", "function f(a) { return a; }"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := calls.bodies["tables.insert"][0]
+	want := `"userDefinedFunctionResources":[{"resourceUri":"gs://bucket/f.js"},{"inlineCode":"function f(a) { return a; }"}]`
+	if !strings.Contains(body, want) || !strings.Contains(body, `"useLegacySql":true`) {
+		t.Errorf("unexpected view %s", body)
+	}
+
+	// standard SQL views do not support UDF resources, nothing is sent
+	err = bq.CreateView(context.Background(), "proj", "views", "v2", "SELECT 1", client.ViewOptions{
+		UserDefinedFunctionResources: []string{"gs://bucket/f.js"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "legacy SQL") {
+		t.Errorf("err = %v, want UDF resources rejected with standard SQL", err)
+	}
+	if n := calls.calls["tables.insert"]; n != 1 {
+		t.Errorf("%d tables.insert calls, want 1", n)
+	}
+}
+
+func TestUpdateViewQuery(t *testing.T) {
+	bq, _, calls := meteredClient(t, "testdata/view_update_query.json")
+
+	if err := bq.UpdateViewQuery(context.Background(), "proj", "views", "v", "SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	if body, want := calls.bodies["tables.patch"][0], `{"view":{"query":"SELECT 2","useLegacySql":false}}`+"\n"; body != want {
+		t.Errorf("patch = %s, want %s", body, want)
+	}
+	if etag := calls.headers["tables.patch"][0].Get("If-Match"); etag != "e1" {
+		t.Errorf("If-Match = %q, want e1", etag)
+	}
+
+	err := bq.UpdateViewQuery(context.Background(), "proj", "views", "t", "SELECT 2")
+	if err == nil || !strings.Contains(err.Error(), "not a view") {
+		t.Errorf("err = %v, want a table rejected", err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+// rowWriter returns the client writer of the output format: table, csv, json or ndjson
+func rowWriter(format string) (func(io.Writer, *client.RowIterator, client.WriteOptions) error, error) {
+	switch format {
+	case "table":
+		return client.WriteTable, nil
+	case "csv":
+		return client.WriteCSV, nil
+	case "json":
+		return client.WriteJSON, nil
+	case "ndjson":
+		return client.WriteNDJSON, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, use table, csv, json or ndjson", format)
+	}
+}
+
+func compactJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// formatBytes returns n in the largest binary unit keeping it above 1, e.g. 1.5 GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
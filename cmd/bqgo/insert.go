@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// insertBatchSize is the number of rows sent in each streaming insert request
+const insertBatchSize = 500
+
+func runInsert(ctx context.Context, e *env, args []string) error {
+	fs := flagSet("insert", "<table>  < rows.ndjson")
+	batchSize := fs.Int("batch-size", insertBatchSize, "rows sent in each insert request")
+	if err := parseFlags(fs, args, 1, 1); err != nil {
+		return err
+	}
+	if *batchSize <= 0 {
+		return fmt.Errorf("invalid -batch-size %d", *batchSize)
+	}
+
+	project, dataset, table, err := e.tableRef(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	inserted := 0
+	flush := func(rows []map[string]interface{}) error {
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := e.client.InsertRowsContext(ctx, project, dataset, table, rows); err != nil {
+			return fmt.Errorf("after %d rows inserted: %v", inserted, err)
+		}
+		inserted += len(rows)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(e.stdin)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	batch := make([]map[string]interface{}, 0, *batchSize)
+	for line := 1; scanner.Scan(); line++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		var row map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err = dec.Decode(&row); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+
+		batch = append(batch, row)
+		if len(batch) == *batchSize {
+			if err = flush(batch); err != nil {
+				return err
+			}
+			batch = make([]map[string]interface{}, 0, *batchSize)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	if err = flush(batch); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "inserted %d rows into %s:%s.%s\n", inserted, project, dataset, table)
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dailyburn/bigquery/client"
+	"google.golang.org/api/iterator"
+)
+
+func runJobs(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: bqgo jobs ls [-all] [-state s] [-since d] [-n max]\n       bqgo jobs cancel <job>...\n")
+		return errUsage
+	}
+
+	project, err := e.requireProject()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "ls":
+		fs := flagSet("jobs ls", "")
+		all := fs.Bool("all", false, "list the jobs of every user")
+		state := fs.String("state", "", "only list jobs in these comma separated states: pending, running, done")
+		since := fs.Duration("since", 0, "only list jobs created in this last duration, e.g. 1h")
+		max := fs.Int("n", 50, "maximum number of jobs listed, 0 for no maximum")
+		if err := parseFlags(fs, args[1:], 0, 0); err != nil {
+			return err
+		}
+
+		filter := client.JobFilter{AllUsers: *all}
+		if len(*state) > 0 {
+			for _, s := range strings.Split(*state, ",") {
+				filter.States = append(filter.States, strings.ToUpper(strings.TrimSpace(s)))
+			}
+		}
+		if *since > 0 {
+			filter.MinCreationTime = time.Now().Add(-*since)
+		}
+
+		tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "JOB\tTYPE\tSTATE\tUSER\tCREATED\tDURATION\tERROR")
+		it := e.client.ListJobs(ctx, project, filter)
+		for n := 0; *max == 0 || n < *max; n++ {
+			job, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				tw.Flush()
+				return err
+			}
+
+			duration := ""
+			if !job.StartTime.IsZero() && !job.EndTime.IsZero() {
+				duration = job.EndTime.Sub(job.StartTime).Round(time.Millisecond).String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", job.JobID, job.Type, job.State, job.UserEmail,
+				job.CreationTime.Format(time.RFC3339), duration, job.ErrorMessage)
+		}
+		return tw.Flush()
+	case "cancel":
+		fs := flagSet("jobs cancel", "<job>...")
+		if err := parseFlags(fs, args[1:], 1, -1); err != nil {
+			return err
+		}
+
+		for _, id := range fs.Args() {
+			job, err := e.client.CancelJob(ctx, project, id, e.location)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(e.stdout, "cancel requested for %s, state %s\n", id, job.State)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown jobs command %q, use ls or cancel", args[0])
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+func runLoad(ctx context.Context, e *env, args []string) error {
+	fs := flagSet("load", "<table> <file | gs://uri>...")
+	format := fs.String("format", "", "source format: csv, ndjson, avro, parquet or orc, guessed from the file extension by default")
+	schemaFile := fs.String("schema", "", "JSON schema file, the table schema is used by default")
+	autodetect := fs.Bool("autodetect", false, "infer the schema from the data")
+	skipRows := fs.Int64("skip-leading-rows", 0, "CSV rows to skip, e.g. 1 for a header row")
+	delimiter := fs.String("field-delimiter", "", "CSV field delimiter, a comma by default")
+	maxBad := fs.Int64("max-bad-records", 0, "rows that can be rejected before the job fails")
+	ignoreUnknown := fs.Bool("ignore-unknown-values", false, "ignore values not in the schema")
+	replace := fs.Bool("replace", false, "replace the table content instead of appending to it")
+	if err := parseFlags(fs, args, 2, -1); err != nil {
+		return err
+	}
+
+	project, dataset, table, err := e.tableRef(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sources := fs.Args()[1:]
+
+	config := client.LoadConfig{
+		Format:              sourceFormat(*format, sources[0]),
+		Autodetect:          *autodetect,
+		SkipLeadingRows:     *skipRows,
+		FieldDelimiter:      *delimiter,
+		MaxBadRecords:       *maxBad,
+		IgnoreUnknownValues: *ignoreUnknown,
+		Location:            e.location,
+	}
+	if *replace {
+		config.WriteDisposition = client.WriteTruncate
+	}
+	if len(*schemaFile) > 0 {
+		if config.Schema, err = readSchema(*schemaFile); err != nil {
+			return err
+		}
+	}
+
+	if strings.HasPrefix(sources[0], "gs://") {
+		for _, src := range sources {
+			if !strings.HasPrefix(src, "gs://") {
+				return fmt.Errorf("can not mix local files and Cloud Storage URIs")
+			}
+		}
+		config.SourceURIs = sources
+	} else {
+		if len(sources) > 1 {
+			return fmt.Errorf("only one local file can be loaded at a time")
+		}
+		f, err := os.Open(sources[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		config.Source = f
+	}
+
+	stats, err := e.client.Load(ctx, project, dataset, table, config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "loaded %d rows (%s) into %s:%s.%s, job %s\n", stats.OutputRows, formatBytes(stats.OutputBytes),
+		project, dataset, table, stats.JobID)
+	if stats.BadRecords > 0 {
+		fmt.Fprintf(e.stdout, "%d bad records were skipped\n", stats.BadRecords)
+	}
+	return nil
+}
+
+func runExtract(ctx context.Context, e *env, args []string) error {
+	fs := flagSet("extract", "<table> <gs://uri>...")
+	format := fs.String("format", "", "destination format: csv, ndjson, avro or parquet, guessed from the URI extension by default")
+	compression := fs.String("compression", "", "GZIP, DEFLATE, SNAPPY or ZSTD, depending on the format")
+	delimiter := fs.String("field-delimiter", "", "CSV field delimiter, a comma by default")
+	noHeader := fs.Bool("no-header", false, "do not write a CSV header row")
+	if err := parseFlags(fs, args, 2, -1); err != nil {
+		return err
+	}
+
+	project, dataset, table, err := e.tableRef(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	uris := fs.Args()[1:]
+	for _, uri := range uris {
+		if !strings.HasPrefix(uri, "gs://") {
+			return fmt.Errorf("tables can only be extracted to Cloud Storage, %q is not a gs:// URI", uri)
+		}
+	}
+
+	stats, err := e.client.Extract(ctx, project, dataset, table, client.ExtractConfig{
+		DestinationURIs: uris,
+		Format:          sourceFormat(*format, strings.TrimSuffix(uris[0], ".gz")),
+		Compression:     *compression,
+		FieldDelimiter:  *delimiter,
+		NoHeader:        *noHeader,
+		Location:        e.location,
+	})
+	if err != nil {
+		return err
+	}
+
+	var files int64
+	for _, n := range stats.DestinationFileCounts {
+		files += n
+	}
+	fmt.Fprintf(e.stdout, "extracted %s:%s.%s to %d files, job %s\n", project, dataset, table, files, stats.JobID)
+	return nil
+}
+
+// sourceFormat returns the client format named by format, or the one matching the extension of path when format is
+// empty, leaving the API default (CSV) for unknown extensions
+func sourceFormat(format, path string) string {
+	if len(format) == 0 {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	switch strings.ToLower(format) {
+	case "csv":
+		return client.FormatCSV
+	case "json", "ndjson", "jsonl":
+		return client.FormatNDJSON
+	case "avro":
+		return client.FormatAvro
+	case "parquet":
+		return client.FormatParquet
+	case "orc":
+		return client.FormatORC
+	default:
+		return strings.ToUpper(format)
+	}
+}
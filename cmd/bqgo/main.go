@@ -0,0 +1,224 @@
+// Command bqgo runs queries, loads and exports data and administers the tables and jobs of BigQuery projects with the
+// client package.
+//
+// Usage:
+//
+//	bqgo [global flags] <command> [flags] [args]
+//
+// The commands are:
+//
+//	query         run a query and print its results
+//	insert        stream the NDJSON rows read from stdin into a table
+//	load          load local files or Cloud Storage URIs into a table
+//	extract       export a table to Cloud Storage
+//	schema        show, diff or apply table schemas
+//	tables        list or remove tables
+//	jobs          list or cancel jobs
+//
+// Credentials are read from the JSON key file given by -credentials, which defaults to
+// $GOOGLE_APPLICATION_CREDENTIALS, like client.New. Without a key file the application default credentials are used.
+// Tables are named project:dataset.table, dataset.table, or table with -dataset.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/dailyburn/bigquery/client"
+	"golang.org/x/oauth2/google"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// env is the configuration shared by the commands
+type env struct {
+	client   *client.Client
+	project  string
+	dataset  string
+	location string
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+// command is a bqgo subcommand, run receives the arguments following its name
+type command struct {
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"query":   {"run a query and print its results", runQuery},
+	"insert":  {"stream the NDJSON rows read from stdin into a table", runInsert},
+	"load":    {"load local files or Cloud Storage URIs into a table", runLoad},
+	"extract": {"export a table to Cloud Storage", runExtract},
+	"schema":  {"show, diff or apply table schemas", runSchema},
+	"tables":  {"list or remove tables", runTables},
+	"jobs":    {"list or cancel jobs", runJobs},
+}
+
+// errUsage is returned by commands invoked with invalid arguments, their usage has already been printed
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	fs := flag.NewFlagSet("bqgo", flag.ContinueOnError)
+	credentials := fs.String("credentials", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), "service account JSON key file")
+	project := fs.String("project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "project the jobs run in and tables belong to")
+	dataset := fs.String("dataset", "", "default dataset of queries and table names")
+	location := fs.String("location", "", "location jobs run in, e.g. US, EU or a region")
+	debug := fs.Bool("debug", false, "log API calls to stderr")
+	largeResults := fs.String("large-results", "", "write query results to temp tables with this name prefix")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: bqgo [global flags] <command> [flags] [args]\n\ncommands:\n")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(fs.Output(), "  %-10s %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintf(fs.Output(), "\nglobal flags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "bqgo: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c, err := newClient(ctx, *credentials, *debug, *largeResults)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bqgo: %v\n", err)
+		return 1
+	}
+
+	e := &env{
+		client:   c,
+		project:  *project,
+		dataset:  *dataset,
+		location: *location,
+		stdin:    os.Stdin,
+		stdout:   os.Stdout,
+	}
+	if err = cmd.run(ctx, e, fs.Args()[1:]); err != nil {
+		if err == errUsage {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "bqgo %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	return 0
+}
+
+// newClient creates a client authorized with the key file, or with the application default credentials when
+// credentials is empty
+func newClient(ctx context.Context, credentials string, debug bool, largeResults string) (*client.Client, error) {
+	var options []func(*client.Client) error
+	if len(credentials) == 0 {
+		hc, err := google.DefaultClient(ctx, bigquery.BigqueryScope)
+		if err != nil {
+			return nil, fmt.Errorf("no -credentials key file and no application default credentials: %v", err)
+		}
+		options = append(options, client.WithHTTPClient(hc))
+	} else if _, err := os.Stat(credentials); err != nil {
+		return nil, err
+	}
+	if len(largeResults) > 0 {
+		options = append(options, client.AllowLargeResults(true, largeResults, false))
+	}
+	if debug {
+		// stdout carries the query results
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		options = append(options, client.WithLogger(logger))
+	}
+
+	c := client.New(credentials, options...)
+	if c == nil {
+		return nil, errors.New("invalid client configuration")
+	}
+	return c, nil
+}
+
+// flagSet returns the flag set of a command, its usage line lists the arguments following the flags
+func flagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: bqgo %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a command and checks the number of remaining arguments, max < 0 means no maximum
+func parseFlags(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() < min || (max >= 0 && fs.NArg() > max) {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// tableRef splits a table name into its project, dataset and table, taking the missing parts from the global flags.
+// Both project:dataset.table and project.dataset.table are accepted
+func (e *env) tableRef(name string) (project, dataset, table string, err error) {
+	project, dataset = e.project, e.dataset
+
+	rest := name
+	if i := strings.Index(rest, ":"); i >= 0 {
+		project, rest = rest[:i], rest[i+1:]
+	}
+
+	parts := strings.Split(rest, ".")
+	switch len(parts) {
+	case 1:
+		table = parts[0]
+	case 2:
+		dataset, table = parts[0], parts[1]
+	case 3:
+		if strings.Contains(name, ":") {
+			return "", "", "", fmt.Errorf("invalid table name %q", name)
+		}
+		project, dataset, table = parts[0], parts[1], parts[2]
+	default:
+		return "", "", "", fmt.Errorf("invalid table name %q", name)
+	}
+
+	if len(project) == 0 || len(dataset) == 0 || len(table) == 0 {
+		return "", "", "", fmt.Errorf("table %q needs a project and a dataset, name them or set -project and -dataset", name)
+	}
+	return project, dataset, table, nil
+}
+
+// requireProject returns the project set with -project
+func (e *env) requireProject() (string, error) {
+	if len(e.project) == 0 {
+		return "", errors.New("-project is required")
+	}
+	return e.project, nil
+}
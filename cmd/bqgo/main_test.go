@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestTableRef(t *testing.T) {
+	e := &env{project: "proj", dataset: "ds"}
+
+	tests := []struct {
+		name                    string
+		project, dataset, table string
+		err                     bool
+	}{
+		{"events", "proj", "ds", "events", false},
+		{"other.events", "proj", "other", "events", false},
+		{"p2:other.events", "p2", "other", "events", false},
+		{"p2.other.events", "p2", "other", "events", false},
+		{"p2:events", "p2", "ds", "events", false},
+		{"p2:a.b.c", "", "", "", true},
+		{"a.b.c.d", "", "", "", true},
+		{"ds.", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		project, dataset, table, err := e.tableRef(tt.name)
+		if (err != nil) != tt.err {
+			t.Errorf("tableRef(%s) err = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		if project != tt.project || dataset != tt.dataset || table != tt.table {
+			t.Errorf("tableRef(%s) = %s, %s, %s, want %s, %s, %s", tt.name, project, dataset, table, tt.project, tt.dataset, tt.table)
+		}
+	}
+
+	// without global flags the project and dataset must be named
+	if _, _, _, err := (&env{}).tableRef("ds.events"); err == nil {
+		t.Error("tableRef(ds.events) succeeded without a project")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+// queryPageSize is the number of rows read and written at a time
+const queryPageSize = 5000
+
+func runQuery(ctx context.Context, e *env, args []string) error {
+	fs := flagSet("query", "<sql | - to read it from stdin>")
+	params := fs.String("params", "", `query parameters, a JSON object for named @params ({"min": 3}) or an array for positional ? params`)
+	dryRun := fs.Bool("dry-run", false, "validate the query and print the bytes it would process without running it")
+	maxBytes := fs.Int64("max-bytes", 0, "fail the query without billing it if it would bill more bytes, 0 means no limit")
+	format := fs.String("format", "table", "output format: table, csv, json or ndjson")
+	legacy := fs.Bool("use-legacy-sql", false, "run the query as legacy SQL instead of standard SQL")
+	batch := fs.Bool("batch", false, "run the query with batch priority")
+	flatten := fs.Bool("flatten", false, "write RECORD fields as dotted columns instead of JSON values")
+	if err := parseFlags(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	project, err := e.requireProject()
+	if err != nil {
+		return err
+	}
+
+	sql := fs.Arg(0)
+	if sql == "-" {
+		b, err := io.ReadAll(e.stdin)
+		if err != nil {
+			return err
+		}
+		sql = string(b)
+	}
+
+	opts := client.QueryOptions{
+		MaximumBytesBilled: *maxBytes,
+		Location:           e.location,
+		UseStandardSQL:     client.Bool(!*legacy),
+	}
+	if *batch {
+		opts.Priority = client.PriorityBatch
+	}
+	if len(*params) > 0 {
+		if opts.Parameters, err = parseParams(*params); err != nil {
+			return err
+		}
+	}
+
+	if *dryRun {
+		stats, err := e.client.DryRun(ctx, e.dataset, project, sql, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "query will process %d bytes (%s)\n", stats.TotalBytesProcessed, formatBytes(stats.TotalBytesProcessed))
+		return nil
+	}
+
+	write, err := rowWriter(*format)
+	if err != nil {
+		return err
+	}
+	writeOpts := client.WriteOptions{}
+	if *flatten {
+		writeOpts.Nested = client.NestedFlatten
+	}
+
+	it := e.client.Rows(ctx, queryPageSize, e.dataset, project, sql, opts)
+	defer it.Close()
+	return write(e.stdout, it, writeOpts)
+}
+
+// parseParams converts the -params JSON to query parameters. Integral numbers become INT64 parameters, other numbers
+// FLOAT64, and arrays of a single type ARRAY parameters
+func parseParams(s string) ([]interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid -params: %v", err)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		params := make([]interface{}, len(names))
+		for i, name := range names {
+			value, err := paramValue(v[name])
+			if err != nil {
+				return nil, fmt.Errorf("invalid -params value for %s: %v", name, err)
+			}
+			params[i] = client.QueryParameter{Name: name, Value: value}
+		}
+		return params, nil
+	case []interface{}:
+		params := make([]interface{}, len(v))
+		for i, pv := range v {
+			value, err := paramValue(pv)
+			if err != nil {
+				return nil, fmt.Errorf("invalid -params value %d: %v", i, err)
+			}
+			params[i] = value
+		}
+		return params, nil
+	default:
+		return nil, errors.New("-params must be a JSON object or array")
+	}
+}
+
+func paramValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case string, bool:
+		return v, nil
+	case []interface{}:
+		return arrayParamValue(v)
+	case nil:
+		return nil, errors.New("null parameters have no type")
+	default:
+		return nil, fmt.Errorf("unsupported parameter %s", compactJSON(v))
+	}
+}
+
+// arrayParamValue converts a JSON array to a typed slice, every element must have the same type. Arrays mixing
+// integral and fractional numbers are FLOAT64 arrays
+func arrayParamValue(values []interface{}) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, errors.New("empty arrays have no type")
+	}
+
+	converted := make([]interface{}, len(values))
+	floats := false
+	for i, v := range values {
+		cv, err := paramValue(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := cv.(float64); ok {
+			floats = true
+		}
+		converted[i] = cv
+	}
+
+	switch converted[0].(type) {
+	case int64, float64:
+		if floats {
+			fs := make([]float64, len(converted))
+			for i, v := range converted {
+				switch n := v.(type) {
+				case int64:
+					fs[i] = float64(n)
+				case float64:
+					fs[i] = n
+				default:
+					return nil, errors.New("arrays must have elements of a single type")
+				}
+			}
+			return fs, nil
+		}
+		is := make([]int64, len(converted))
+		for i, v := range converted {
+			n, ok := v.(int64)
+			if !ok {
+				return nil, errors.New("arrays must have elements of a single type")
+			}
+			is[i] = n
+		}
+		return is, nil
+	case string:
+		ss := make([]string, len(converted))
+		for i, v := range converted {
+			s, ok := v.(string)
+			if !ok {
+				return nil, errors.New("arrays must have elements of a single type")
+			}
+			ss[i] = s
+		}
+		return ss, nil
+	case bool:
+		bs := make([]bool, len(converted))
+		for i, v := range converted {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, errors.New("arrays must have elements of a single type")
+			}
+			bs[i] = b
+		}
+		return bs, nil
+	default:
+		return nil, errors.New("arrays of arrays are not supported")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		params string
+		want   []interface{}
+		err    bool
+	}{
+		{`[1, 1.5, "a", true]`, []interface{}{int64(1), 1.5, "a", true}, false},
+		{`{"b": [1, 2], "a": "x"}`, []interface{}{
+			client.QueryParameter{Name: "a", Value: "x"},
+			client.QueryParameter{Name: "b", Value: []int64{1, 2}},
+		}, false},
+		{`[12345678901234567890]`, []interface{}{1.2345678901234567e19}, false},
+		{`[null]`, nil, true},
+		{`{"a": {"b": 1}}`, nil, true},
+		{`"a"`, nil, true},
+		{`[1,`, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseParams(tt.params)
+		if (err != nil) != tt.err {
+			t.Errorf("parseParams(%s) err = %v, want error %v", tt.params, err, tt.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseParams(%s) = %#v, want %#v", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestArrayParamValue(t *testing.T) {
+	tests := []struct {
+		values []interface{}
+		want   interface{}
+		err    bool
+	}{
+		{[]interface{}{json.Number("1"), json.Number("2")}, []int64{1, 2}, false},
+		{[]interface{}{json.Number("1"), json.Number("2.5")}, []float64{1, 2.5}, false},
+		{[]interface{}{json.Number("2.5"), json.Number("1")}, []float64{2.5, 1}, false},
+		{[]interface{}{"a", "b"}, []string{"a", "b"}, false},
+		{[]interface{}{true, false}, []bool{true, false}, false},
+		{[]interface{}{}, nil, true},
+		{[]interface{}{"a", json.Number("1")}, nil, true},
+		{[]interface{}{json.Number("1"), "a"}, nil, true},
+		{[]interface{}{[]interface{}{"a"}}, nil, true},
+		{[]interface{}{nil}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := arrayParamValue(tt.values)
+		if (err != nil) != tt.err {
+			t.Errorf("arrayParamValue(%v) err = %v, want error %v", tt.values, err, tt.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("arrayParamValue(%v) = %#v, want %#v", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dailyburn/bigquery/client"
+)
+
+func runSchema(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: bqgo schema show <table>\n       bqgo schema diff <table> <schema.json>\n       bqgo schema apply [-plan] <table> <schema.json>\n")
+		return errUsage
+	}
+
+	switch args[0] {
+	case "show":
+		fs := flagSet("schema show", "<table>")
+		if err := parseFlags(fs, args[1:], 1, 1); err != nil {
+			return err
+		}
+		project, dataset, table, err := e.tableRef(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		md, err := e.client.GetTable(ctx, project, dataset, table)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(md.Schema)
+	case "diff":
+		fs := flagSet("schema diff", "<table> <schema.json>")
+		if err := parseFlags(fs, args[1:], 2, 2); err != nil {
+			return err
+		}
+		project, dataset, table, err := e.tableRef(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		desired, err := readSchema(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+
+		md, err := e.client.GetTable(ctx, project, dataset, table)
+		if err != nil {
+			return err
+		}
+		changes := client.DiffSchema(md.Schema, desired)
+		if len(changes) == 0 {
+			fmt.Fprintln(e.stdout, "no changes")
+		}
+		for _, change := range changes {
+			fmt.Fprintln(e.stdout, change)
+		}
+		return nil
+	case "apply":
+		fs := flagSet("schema apply", "<table> <schema.json>")
+		plan := fs.Bool("plan", false, "print the changes without applying them")
+		if err := parseFlags(fs, args[1:], 2, 2); err != nil {
+			return err
+		}
+		project, dataset, table, err := e.tableRef(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		desired, err := readSchema(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+
+		changes, err := e.client.EvolveSchema(ctx, project, dataset, table, desired, client.SchemaPolicy{PlanOnly: *plan, Out: e.stdout})
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Fprintln(e.stdout, "no changes")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown schema command %q, use show, diff or apply", args[0])
+	}
+}
+
+// readSchema reads a schema file in the format of the bq command line tool
+func readSchema(path string) (client.Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return client.SchemaFromJSON(f)
+}
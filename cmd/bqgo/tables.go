@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dailyburn/bigquery/client"
+	"google.golang.org/api/iterator"
+)
+
+func runTables(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: bqgo tables ls [-prefix p] [dataset]\n       bqgo tables rm [-f] <table>...\n")
+		return errUsage
+	}
+
+	switch args[0] {
+	case "ls":
+		fs := flagSet("tables ls", "[project:dataset | dataset]")
+		prefix := fs.String("prefix", "", "only list tables with this ID prefix")
+		tableType := fs.String("type", "", "only list tables of this type: TABLE, VIEW, MATERIALIZED_VIEW or EXTERNAL")
+		if err := parseFlags(fs, args[1:], 0, 1); err != nil {
+			return err
+		}
+
+		project, dataset := e.project, e.dataset
+		if fs.NArg() == 1 {
+			// parse the dataset as a table name with a placeholder table
+			var err error
+			if project, dataset, _, err = e.tableRef(fs.Arg(0) + ".t"); err != nil {
+				return err
+			}
+		}
+		if len(project) == 0 || len(dataset) == 0 {
+			return fmt.Errorf("a dataset is required, name it or set -project and -dataset")
+		}
+
+		tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TABLE\tTYPE\tCREATED\tLABELS")
+		it := e.client.ListTables(ctx, project, dataset, client.TableFilter{Prefix: *prefix, Type: *tableType})
+		for {
+			md, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				tw.Flush()
+				return err
+			}
+			labels := ""
+			if len(md.Labels) > 0 {
+				labels = compactJSON(md.Labels)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", md.TableID, md.Type, md.CreationTime.Format(time.RFC3339), labels)
+		}
+		return tw.Flush()
+	case "rm":
+		fs := flagSet("tables rm", "<table>...")
+		force := fs.Bool("f", false, "ignore tables that do not exist")
+		if err := parseFlags(fs, args[1:], 1, -1); err != nil {
+			return err
+		}
+
+		for _, name := range fs.Args() {
+			project, dataset, table, err := e.tableRef(name)
+			if err != nil {
+				return err
+			}
+			if err = e.client.DeleteTable(ctx, project, dataset, table, *force); err != nil {
+				return err
+			}
+			fmt.Fprintf(e.stdout, "removed %s:%s.%s\n", project, dataset, table)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown tables command %q, use ls or rm", args[0])
+	}
+}
@@ -31,6 +31,10 @@ L:
 				fmt.Println("Headers: ", d.Headers)
 			}
 
+			if d.Stats != nil {
+				fmt.Println("Bytes billed: ", d.Stats.TotalBytesBilled)
+			}
+
 			if !ok {
 				fmt.Println("Data channel closed")
 				break L